@@ -2,25 +2,40 @@ package cmd
 
 import (
 	"betterbetter/src"
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
 func init() {
 
-  var StatsPath string
-  var OddsPath string
+	var StatsPath string
+	var OddsPath string
+	var At string
+	var Sport string
+
+	arbCMD.Flags().StringVarP(&StatsPath, "stats", "s", "", "Path to stats data")
+	arbCMD.Flags().StringVarP(&OddsPath, "odds", "o", "", "Path to odds data")
+	arbCMD.Flags().StringVar(&Sport, "sport", "", "Sport whose markets to price when the odds path does not name one")
+	arbCMD.Flags().StringVar(&At, "at", "", "Compare against the line at this time: close, day or a duration before tip-off like 6h")
 
-  arbCMD.Flags().StringVarP(&StatsPath, "stats", "s", "", "Path to stats data")
-  arbCMD.Flags().StringVarP(&OddsPath, "odds", "o", "", "Path to odds data")
+	arbCMD.Flags().Float64Var(&src.PlayerMatchThreshold, "match-threshold", src.PlayerMatchThreshold, "Lowest confidence accepted when fuzzy matching players to sportsbook names")
 
-  rootCmd.AddCommand(arbCMD)
+	rootCmd.AddCommand(arbCMD)
 }
 
 var arbCMD = &cobra.Command{
-  Use:   "arbitrage",
-  Short: "Print the version number of betterbetter",
-  Long:  `All software has versions. This is betterbetter's`,
-  Run: func(cmd *cobra.Command, args []string) {
-    src.Arbitrage(cmd.Flag("stats").Value.String(), cmd.Flag("odds").Value.String())
-  },
-}
\ No newline at end of file
+	Use:   "arbitrage",
+	Short: "Print the version number of betterbetter",
+	Long:  `All software has versions. This is betterbetter's`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := src.OpenStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer store.Close()
+
+		src.Arbitrage(store, cmd.Flag("sport").Value.String(), cmd.Flag("stats").Value.String(), cmd.Flag("odds").Value.String(), cmd.Flag("at").Value.String())
+	},
+}
@@ -3,10 +3,7 @@ package cmd
 import (
 	"betterbetter/src"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"math"
-	"os"
 	"strconv"
 	"strings"
 
@@ -18,11 +15,27 @@ func init() {
 	var chains int
 	var trainSamples int
 	var testSamples int
+	var maxRHat float64
+	var minESS float64
+	var minAcceptance float64
+	var maxDivergences int
+	var strict bool
+	var sampler string
+	var likelihoods map[string]string
+	var model string
 
 	bayesCmd.Flags().IntVarP(&lags, "lags", "l", 4, "Number of lags")
 	bayesCmd.Flags().IntVarP(&chains, "chains", "c", 4, "Number of chains")
 	bayesCmd.Flags().IntVarP(&trainSamples, "train", "e", 5, "Number of posterior predictive examples")
 	bayesCmd.Flags().IntVarP(&testSamples, "test", "s", 500, "Number of samples for posterior predictive")
+	bayesCmd.Flags().StringVar(&sampler, "sampler", "Metropolis", "MCMC sampler: Metropolis, Adaptive, NUTS, Hamiltonian, Unit, Lattice or Gaussian")
+	bayesCmd.Flags().StringToStringVar(&likelihoods, "likelihood", nil, "Likelihood per metric, e.g. blocks=NegativeBinomial,steals=ZeroInflatedPoisson (default Normal)")
+	bayesCmd.Flags().StringVar(&model, "model", "", "Model file (YAML or JSON) of priors, likelihood, link, lags and sampler per metric and sport, overriding -l, --sampler and --likelihood")
+	bayesCmd.Flags().Float64Var(&maxRHat, "max-rhat", 1.01, "Flag models whose split R-hat is above this (0 to disable)")
+	bayesCmd.Flags().Float64Var(&minESS, "min-ess", 400, "Flag models whose bulk or tail ESS is below this (0 to disable)")
+	bayesCmd.Flags().Float64Var(&minAcceptance, "min-acceptance", 0, "Flag models whose acceptance rate is below this (0 to disable)")
+	bayesCmd.Flags().IntVar(&maxDivergences, "max-divergences", 0, "Flag models with more divergent transitions than this (-1 to disable)")
+	bayesCmd.Flags().BoolVar(&strict, "strict", false, "Drop the predictions of flagged models instead of saving them")
 
 	rootCmd.AddCommand(bayesCmd)
 }
@@ -34,7 +47,13 @@ var bayesCmd = &cobra.Command{
 	Short: "Bayesian Stats",
 	Long:  `Bayesian Stats`,
 	Run: func(cmd *cobra.Command, args []string) {
-		dir, err := ioutil.ReadDir("data")
+		store, err := src.OpenStore()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		keys, err := store.StatKeys()
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -49,181 +68,174 @@ var bayesCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		for _, folder := range dir {
-			years, err := ioutil.ReadDir("data/" + folder.Name())
-			if err != nil {
+		var thresholds src.DiagnosticThresholds
+		if thresholds.MaxRHat, err = cmd.Flags().GetFloat64("max-rhat"); err != nil {
+			log.Fatal(err)
+		}
+		if thresholds.MinESS, err = cmd.Flags().GetFloat64("min-ess"); err != nil {
+			log.Fatal(err)
+		}
+		if thresholds.MinAcceptance, err = cmd.Flags().GetFloat64("min-acceptance"); err != nil {
+			log.Fatal(err)
+		}
+		if thresholds.MaxDivergences, err = cmd.Flags().GetInt("max-divergences"); err != nil {
+			log.Fatal(err)
+		}
+		strict, err := cmd.Flags().GetBool("strict")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		likelihoods, err := cmd.Flags().GetStringToString("likelihood")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var modelFile *src.ModelFile
+		if path := cmd.Flag("model").Value.String(); path != "" {
+			if modelFile, err = src.LoadModelFile(path); err != nil {
 				log.Fatal(err)
 			}
-			for _, year := range years {
-				teams, err := ioutil.ReadDir("data/" + folder.Name() + "/" + year.Name())
-				if err != nil {
-					log.Fatal(err)
+		}
+
+		// modelSpec is the model of a sport's metric: the flags, overridden
+		// by the model file
+		modelSpec := func(sport string, metric string) src.ModelSpec {
+			base := src.ModelSpec{
+				Likelihood: likelihoods[metric],
+				Covariates: src.CovariateSpec{Lags: lags},
+				Sampler:    cmd.Flag("sampler").Value.String(),
+			}
+			return modelFile.Spec(base, sport, metric)
+		}
+
+		// Check every model before sampling any
+		for _, key := range keys {
+			for _, metric := range src.SportMetrics(key.Sport) {
+				if err := modelSpec(key.Sport, metric).Validate(); err != nil {
+					log.Fatalf("model for %s %s: %v", key.Sport, metric, err)
 				}
-				for _, team := range teams {
-					data, err := ioutil.ReadDir("data/" + folder.Name() + "/" + year.Name() + "/" + team.Name())
+			}
+		}
+
+		for _, key := range keys {
+			if _, err := src.GetSport(key.Sport); err != nil {
+				fmt.Printf("Skipping %s: %v\n", key, err)
+				continue
+			}
+			statsData, err := store.LoadGameLines(key)
+			if err != nil {
+				fmt.Printf("Skipping %s: %v\n", key, err)
+				continue
+			}
+
+			timeseries := CreateTimeseries(statsData, src.SportMetrics(key.Sport))
+
+			for player, data := range timeseries {
+
+				playerPreds := map[string]map[string][]float64{player: {}}
+				playerModels := map[string]src.ModelRecord{}
+				playerDiagnostics := map[string]src.DiagnosticsRecord{}
+
+				for name, metric := range data {
+					model, err := modelSpec(key.Sport, name).Build(metric)
 					if err != nil {
-						log.Fatal(err)
+						fmt.Printf("Skipping %s %s: %v\n", player, name, err)
+						continue
 					}
-					for _, file := range data {
-						if strings.Contains(file.Name(), "stats.json") {
-							stats, err := os.Open("data/" + folder.Name() + "/" + year.Name() + "/" + team.Name() + "/" + file.Name())
-							if err != nil {
-								log.Fatal(err)
+					lags := model.Spec.Covariates.Lags
+					lagMatrix := CreateLags(metric, lags)
+
+					trainSamples, _ := strconv.Atoi(cmd.Flag("train").Value.String())
+
+					// Training data: exclude last `testSamples` observations
+					trainSize := trainSamples
+
+					if trainSize > 0 && len(lagMatrix) >= trainSize && len(lagMatrix[:trainSize]) == trainSize {
+						fmt.Println("Training on", player, "with", trainSize, "samples")
+						lagmatTrain := mat.NewDense(trainSize, len(lagMatrix[0]), nil)
+						for i, lag := range lagMatrix[:trainSize] {
+							for j, val := range lag {
+								lagmatTrain.Set(i, j, val)
 							}
+						}
+
+						// Features of the upcoming game: the last `lags` observations
+						nextFeatures := metric[len(metric)-lags:]
+
+						// Output data: assume metric is aligned with lagMatrix, training excludes last testSamples
+						metricTrain := metric[:len(metric)-trainSamples]
+
+						initialParams := make([]float64, len(model.Priors))
+						for i := range initialParams {
+							initialParams[i] = 1.0
+						}
+
+						likelihood := src.Likelihood{
+							Params:             initialParams,
+							DistributionParams: model.Likelihood,
+							InputData:          *lagmatTrain,
+							OutputData:         *mat.NewVecDense(len(metricTrain), metricTrain),
+							Link:               model.Link,
+							LinkJacobian:       model.LinkJacobian,
+						}
+
+						mc := model.NewMarkovChain(likelihood)
+
+						posterior := src.Posterior{
+							Priors:           model.Priors,
+							Data:             *lagmatTrain,
+							LikelihoodParams: model.Likelihood,
+							MarkovChain:      mc,
+							Seed:             src.DeriveSeed(src.Seed, key.String(), player, name),
+						}
+
+						fmt.Println("Calculating Posterior for", player, "with", len(metricTrain), "training samples")
+
+						chainResults := posterior.CalcPosterior(chains)
+						posteriorResults := src.PoolChains(chainResults)
 
-							rawData, err := ioutil.ReadAll(stats)
-							if err != nil {
-								log.Fatal(err)
+						diagnostics := src.Diagnose(chainResults, model.ParamNames)
+						printDiagnostics(player, name, diagnostics)
+						if mc.Sampler == "NUTS" {
+							printSamplerStats(chainResults)
+						}
+						problems := diagnostics.Check(thresholds)
+						if len(problems) > 0 {
+							fmt.Printf("Flagged %s %s: %s\n", player, name, strings.Join(problems, "; "))
+							if strict {
+								continue
 							}
-							statsData := src.ParseData(string(rawData))["response"]
-
-							timeseries := CreateTimeseries(statsData.([]interface{}))
-
-							for player, data := range timeseries {
-
-								playerPreds := make(map[string][][]float64)
-
-								for name, metric := range data {
-									lagMatrix := CreateLags(metric, lags)
-
-									// Create priors dynamically based on number of lags
-									var priors []src.DistributionParams
-									for i := 0; i < lags; i++ {
-										priorRateParams := src.DistributionParams{
-											Dist: "Uniform",
-											Params: map[string]float64{
-												"Min": 0,
-												"Max": 1,
-											},
-										}
-										priors = append(priors, priorRateParams)
-									}
-
-									interceptMean := src.Sum(metric) / float64(len(metric))
-									interceptParams := src.DistributionParams{
-										Dist: "Normal",
-										Params: map[string]float64{
-											"Mu":    interceptMean,
-											"Sigma": math.Sqrt(1 + interceptMean),
-										},
-									}
-									priors = append(priors, interceptParams)
-
-									// Create Likelihood
-									likelihoodParams := src.DistributionParams{
-										Dist: "Normal",
-										Params: map[string]float64{
-											"Mu":    0,
-											"Sigma": 1,
-										},
-									}
-
-									linkFunc := func(point []float64, data []float64) []float64 {
-										lambda := 0.0
-										for i, val := range data {
-											lambda += val * point[i]
-										}
-										lambda += point[len(point)-1] // intercept
-										return []float64{math.Max(lambda, 0), math.Abs(lambda)}
-									}
-
-									trainSamples, _ := strconv.Atoi(cmd.Flag("train").Value.String())
-
-									// Training data: exclude last `testSamples` observations
-									trainSize := trainSamples
-								
-									if trainSize > 0 && len(lagMatrix) >= trainSize && len(lagMatrix[:trainSize]) == trainSize {
-										fmt.Println("Training on", player, "with", trainSize, "samples")
-										lagmatTrain := mat.NewDense(trainSize, len(lagMatrix[0]), nil)
-										for i, lag := range lagMatrix[:trainSize] {
-											for j, val := range lag {
-												lagmatTrain.Set(i, j, val)
-											}
-										}
-
-
-										// Test data: last `testSamples` rows
-										lagmatTest := mat.NewDense(testSamples, len(lagMatrix[0]), nil)
-										for i, lag := range lagMatrix[trainSize:] {
-											for j, val := range lag {
-												lagmatTest.Set(i, j, val)
-											}
-										}
-
-										// Output data: assume metric is aligned with lagMatrix, training excludes last testSamples
-										metricTrain := metric[:len(metric)-trainSamples]
-
-										initialParams := make([]float64, lags+1)
-										for i := range initialParams {
-											initialParams[i] = 1.0
-										}
-
-										likelihood := src.Likelihood{
-											Params:             initialParams,
-											DistributionParams: likelihoodParams,
-											InputData:          *lagmatTrain,
-											OutputData:         *mat.NewVecDense(len(metricTrain), metricTrain),
-											Link:               linkFunc,
-										}
-
-										mc := src.MarkovChain{
-											Distributions: priors,
-											Grid:          mat.Dense{},
-											Likelihood:    likelihood,
-											SampleSize:    25,
-											Sampler:       "Metropolis",
-										}
-
-										posterior := src.Posterior{
-											Priors:           priors,
-											Data:             *lagmatTrain,
-											LikelihoodParams: likelihoodParams,
-											MarkovChain:      mc,
-										}
-
-										fmt.Println("Calculating Posterior for", player, "with", len(metricTrain), "training samples")
-
-										posteriorResults := posterior.CalcPosterior(chains)
-										
-										fmt.Println(lagmatTrain)
-
-										// Updated call to CalcPosteriorPredictive with multiple test examples
-										// We'll pass the entire lagmatTest data and let the function handle multiple rows.
-										// Convert lagmatTest (a mat.Dense) to [][]float64
-										rows, cols := lagmatTest.Dims()
-										testData := make([][]float64, rows)
-										for i := 0; i < rows; i++ {
-												testData[i] = make([]float64, cols)
-												for j := 0; j < cols; j++ {
-														testData[i][j] = lagmatTest.At(i, j)
-												}
-										}
-
-										fmt.Println("Calculating Posterior Predictive for", player, "with metric " , name)
-
-										// Now call CalcPosteriorPredictive with the testData as [][]float64
-										postPred := posterior.CalcPosteriorPredictive(
-												posteriorResults,
-												testData,
-												testSamples,
-												linkFunc,
-										)
-
-										postPredFiltered := make([]float64, len(postPred))
-										// take min value and add that to every element
-										for _, val := range postPred {
-												if val > 0 {
-													postPredFiltered = append(postPredFiltered, val)
-												}
-										}
-										
-
-										playerPreds[player] = append(playerPreds[player], postPredFiltered)
-										src.SaveToFile(playerPreds, "data/"+folder.Name()+"/"+year.Name()+"/"+team.Name()+"/preds/", player+"_preds.json")
-										}
-								}
+						}
+
+						fmt.Println("Calculating Posterior Predictive for", player, "with metric ", name)
+
+						postPred := posterior.CalcPosteriorPredictive(posteriorResults, nextFeatures, testSamples, model.Link)
+
+						postPredFiltered := make([]float64, 0, len(postPred))
+						// take min value and add that to every element
+						for _, val := range postPred {
+							if val >= 0 {
+								postPredFiltered = append(postPredFiltered, val)
 							}
 						}
+
+						playerPreds[player][name] = postPredFiltered
+						if err := store.SavePredictions(key, player, playerPreds[player]); err != nil {
+							fmt.Println(err)
+						}
+						playerModels[name] = model.Record(mc)
+						playerDiagnostics[name] = src.DiagnosticsRecord{Diagnostics: diagnostics, Problems: problems}
+						inputs := []src.ManifestInput{src.InputOf(key.StatsRef(), statsData)}
+						if modelFile != nil {
+							inputs = append(inputs, modelFile.Input())
+						}
+						manifest := src.NewManifest(key.PredsRef(player), inputs...)
+						manifest.Models = playerModels
+						manifest.Diagnostics = playerDiagnostics
+						if err := store.SaveManifest(manifest); err != nil {
+							fmt.Println(err)
+						}
 					}
 				}
 			}
@@ -231,44 +243,38 @@ var bayesCmd = &cobra.Command{
 	},
 }
 
-func CreateTimeseries(data []interface{}) map[string][][]float64 {
-	playerData := make(map[string][][]float64)
-
-	for _, gd := range data {
-		gameData := gd.(map[string]interface{})
-		player := gameData["player"].(map[string]interface{})
-		firstName := player["firstname"].(string)
-		lastName := player["lastname"].(string)
-
-		name := firstName + "_" + lastName
-		points := gameData["points"].(float64)
-		rebounds := gameData["totReb"].(float64)
-		assists := gameData["assists"].(float64)
-		blocks := gameData["blocks"].(float64)
-		steals := gameData["steals"].(float64)
-		turnovers := gameData["turnovers"].(float64)
+// printDiagnostics prints the convergence diagnostics of one model, naming
+// the parameters as the model does: lags, intercept, then any further
+// parameters of the likelihood
+func printDiagnostics(player string, metric string, d src.Diagnostics) {
+	fmt.Printf("Diagnostics for %s %s: %d chains of %d draws, acceptance %.3f\n", player, metric, d.Chains, d.Draws, d.Acceptance)
+	for _, p := range d.Params {
+		fmt.Printf("  %-10s acceptance %5.3f  R-hat %6.3f  bulk ESS %7.0f  tail ESS %7.0f  lag-1 autocorr %6.3f\n", p.Label(), p.Acceptance, p.RHat, p.BulkESS, p.TailESS, p.Autocorr)
+	}
+}
 
-		playerData[name] = append(playerData[name], []float64{points, rebounds, assists, blocks, steals, turnovers})
+// printSamplerStats prints the step size, divergences and tree depths of
+// every chain of a gradient-based sampler
+func printSamplerStats(chains []src.ChainResult) {
+	for _, c := range chains {
+		s := c.Stats
+		fmt.Printf("  chain %d    step size %.4g  divergences %d  tree depth mean %.2f max %d (%d at limit)\n",
+			c.Chain, s.StepSize, s.Divergences, s.MeanTreeDepth, s.MaxTreeDepth, s.DepthLimitHit)
 	}
+}
 
-	for player, pdata := range playerData {
-		points := make([]float64, len(pdata))
-		rebounds := make([]float64, len(pdata))
-		assists := make([]float64, len(pdata))
-		blocks := make([]float64, len(pdata))
-		steals := make([]float64, len(pdata))
-		turnovers := make([]float64, len(pdata))
-
-		for i, gameData := range pdata {
-			points[i] = gameData[0]
-			rebounds[i] = gameData[1]
-			assists[i] = gameData[2]
-			blocks[i] = gameData[3]
-			steals[i] = gameData[4]
-			turnovers[i] = gameData[5]
-		}
+// CreateTimeseries collects each player's series of every metric, in the
+// order the games appear in data
+func CreateTimeseries(data []src.GameLine, metrics []string) map[string]map[string][]float64 {
+	playerData := make(map[string]map[string][]float64)
 
-		playerData[player] = [][]float64{points, rebounds, assists, blocks, steals, turnovers}
+	for _, gameData := range data {
+		if playerData[gameData.Player] == nil {
+			playerData[gameData.Player] = make(map[string][]float64)
+		}
+		for _, metric := range metrics {
+			playerData[gameData.Player][metric] = append(playerData[gameData.Player][metric], gameData.Stats[metric])
+		}
 	}
 
 	return playerData
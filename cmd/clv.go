@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"betterbetter/src"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+
+	var BetsPath string
+
+	clvCMD.Flags().StringVarP(&BetsPath, "bets", "b", "", "Path to a bets.json file (default the stored bets)")
+	rootCmd.AddCommand(clvCMD)
+}
+
+var clvCMD = &cobra.Command{
+	Use:   "clv",
+	Short: "Closing line value of generated bets",
+	Long: `Compare the price of every bet in bets.json to the closing price of the
+same market, outcome and point, taken from the last odds snapshot stored at
+or before tip-off (see fetchodds --snapshots). Writes clv.json next to the
+bets file, or to the data directory for the stored bets.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		betsPath := cmd.Flag("bets").Value.String()
+
+		store, err := src.OpenStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer store.Close()
+
+		report, inputs, err := src.ComputeCLV(store, betsPath)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		for _, r := range report.Bets {
+			fmt.Printf("%-25s %-24s %-5s %6v  taken %.2f  close %.2f  CLV %+.1f%%\n",
+				r.Bet.Description, r.Bet.Type, r.Bet.Name, pointString(r.Bet.Point), r.Bet.Price, r.ClosingPrice, r.CLV*100)
+		}
+		fmt.Printf("\n%d bets without a closing line\n\n", len(report.Unmatched))
+
+		printCLVSummary("Overall", map[string]src.CLVSummary{"all": report.Overall})
+		printCLVSummary("By market", report.ByMarket)
+		printCLVSummary("By bookmaker", report.ByBookmaker)
+		printCLVSummary("By player", report.ByPlayer)
+
+		outDir := src.DataDir
+		if betsPath != "" {
+			outDir = filepath.Dir(betsPath)
+		}
+		if err := src.SaveToFile(report, outDir, "clv.json"); err != nil {
+			fmt.Printf("Error saving CLV report: %v\n", err)
+			return
+		}
+		clvPath := filepath.Join(outDir, "clv.json")
+		if err := src.SaveFileManifest(clvPath, src.NewManifest(src.RefFromPath(clvPath), inputs...)); err != nil {
+			fmt.Printf("Error saving CLV manifest: %v\n", err)
+		}
+	},
+}
+
+func printCLVSummary(title string, summaries map[string]src.CLVSummary) {
+	fmt.Println(title)
+	for _, key := range src.SortedKeys(summaries) {
+		s := summaries[key]
+		fmt.Printf("  %-25s n=%-4d mean CLV %+.1f%%  prob edge %+.3f  beat close %.0f%%\n", key, s.Count, s.MeanCLV*100, s.MeanEdge, s.BeatClose*100)
+	}
+	fmt.Println()
+}
+
+func pointString(point *float64) string {
+	if point == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%v", *point)
+}
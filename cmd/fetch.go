@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"betterbetter/src"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"github.com/spf13/cobra"
-	"strings"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,7 +17,7 @@ type mapFunc[E any] func(E) E
 func Map[S ~[]E, E any](s S, f mapFunc[E]) S {
 	result := make(S, len(s))
 	for i := range s {
-			result[i] = f(s[i])
+		result[i] = f(s[i])
 	}
 	return result
 }
@@ -24,20 +26,56 @@ func TrimBracket(s string) string {
 	return strings.Trim(s, "[]")
 }
 
+// setupCassette puts the fetch layer into record or replay mode from flags
+func setupCassette(cmd *cobra.Command) error {
+	if dir := cmd.Flag("record").Value.String(); dir != "" {
+		return src.UseCassette("record", dir)
+	}
+	if dir := cmd.Flag("replay").Value.String(); dir != "" {
+		return src.UseCassette("replay", dir)
+	}
+	return src.UseCassette("", "")
+}
+
 func init() {
 	var Year string
 	var Sport string
 	var Teams []string
 	var Players []string
+	var DataProvider string
+	var OddsProvider string
+	var Record string
+	var Replay string
+	var Concurrency int
+	var Incremental bool
+	var From string
+	var To string
+	var Season string
+	var Restart bool
+	var Snapshots string
 
 	FetchDataCmd.Flags().StringVarP(&Sport, "sport", "s", "", "Sport to fetch data for")
 	FetchDataCmd.Flags().StringSliceVarP(&Players, "players", "p", []string{}, "players to fetch data for")
 	FetchDataCmd.Flags().StringSliceVarP(&Teams, "teams", "t", []string{}, "teams to fetch data for")
 	FetchDataCmd.Flags().StringVarP(&Year, "year", "y", "", "Year to fetch data for")
+	FetchDataCmd.Flags().StringVar(&DataProvider, "provider", "api-sports", "Data provider to fetch from")
+	FetchDataCmd.Flags().BoolVar(&Incremental, "incremental", false, "Only fetch games newer than those already stored and merge them in")
+	FetchDataCmd.Flags().IntVar(&Concurrency, "concurrency", 4, "Number of teams and players to fetch in parallel")
 
 	FetchOddsCmd.Flags().StringVarP(&Sport, "sport", "s", "", "Sport to fetch odds for")
 	FetchOddsCmd.Flags().StringVarP(&Year, "date", "d", "", "YYYY-MM-DD date to fetch odds for")
-
+	FetchOddsCmd.Flags().StringVar(&From, "from", "", "First YYYY-MM-DD date of a range to fetch odds for")
+	FetchOddsCmd.Flags().StringVar(&To, "to", "", "Last YYYY-MM-DD date of a range to fetch odds for")
+	FetchOddsCmd.Flags().StringVar(&Season, "season", "", "Season whose stored schedule provides the dates to fetch")
+	FetchOddsCmd.Flags().StringVar(&Snapshots, "snapshots", "", "Extra odds snapshots per event, e.g. 72h,24h,6h,1h,close (durations before tip-off)")
+	FetchOddsCmd.Flags().BoolVar(&Restart, "restart", false, "Ignore progress saved by an earlier ranged fetch")
+	FetchOddsCmd.Flags().StringVar(&OddsProvider, "provider", "the-odds-api", "Odds provider to fetch from")
+
+	for _, c := range []*cobra.Command{FetchDataCmd, FetchOddsCmd} {
+		c.Flags().StringVar(&Record, "record", "", "Record every HTTP response into this directory")
+		c.Flags().StringVar(&Replay, "replay", "", "Serve HTTP responses from this directory instead of the network")
+		c.MarkFlagsMutuallyExclusive("record", "replay")
+	}
 
 	rootCmd.AddCommand(FetchDataCmd)
 	rootCmd.AddCommand(FetchOddsCmd)
@@ -52,299 +90,657 @@ var FetchDataCmd = &cobra.Command{
 		sport := cmd.Flag("sport").Value.String()
 		year := cmd.Flag("year").Value.String()
 
+		provider, err := src.GetProvider(cmd.Flag("provider").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := setupCassette(cmd); err != nil {
+			fmt.Println(err)
+			return
+		}
+
 		teams := strings.Split(cmd.Flag("teams").Value.String(), ",")
 		teams = Map(teams, TrimBracket)
 
 		fmt.Println(teams)
 
-
-		teampaths := []string{}
-		if teams[0] != "" {
-			for _, team := range teams {
-				teampaths = append(teampaths, fmt.Sprintf("data/%s/%s/%s", sport, year, team))
-			}
-	  }
+		if _, err := src.GetSport(sport); err != nil {
+			fmt.Println(err)
+			return
+		}
 
 		players := strings.Split(cmd.Flag("players").Value.String(), ",")
 		players = Map(players, TrimBracket)
 
-		playerpaths := []string{}
+		store, err := src.OpenStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer store.Close()
+
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		incremental, err := cmd.Flags().GetBool("incremental")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		var jobs []fetchJob
+		if teams[0] != "" {
+			for _, team := range teams {
+				team := strings.Trim(team, "[]")
+				jobs = append(jobs, fetchJob{Kind: "team", Name: team, Run: func() error {
+					return fetchTeam(store, provider, sport, year, team, incremental)
+				}})
+			}
+		}
 		if players[0] != "" {
 			for _, player := range players {
-				playerpaths = append(playerpaths, fmt.Sprintf("data/%s/%s/%s", sport, year, player))
+				player := strings.Trim(player, "[]")
+				jobs = append(jobs, fetchJob{Kind: "player", Name: player, Run: func() error {
+					return fetchPlayer(store, provider, sport, year, player)
+				}})
 			}
 		}
 
-		os.Mkdir(fmt.Sprintf("data/%s", sport), os.ModePerm)
-		os.Mkdir(fmt.Sprintf("data/%s/%s", sport, year), os.ModePerm)
+		failures := runFetchJobs(jobs, concurrency)
 
-		if len(teampaths) > 0 {
-			for _, path := range teampaths {
-				os.Mkdir(path, os.ModePerm)
-			}
+		fmt.Printf("Fetched %d of %d entities\n", len(jobs)-len(failures), len(jobs))
+		for _, failure := range failures {
+			fmt.Printf("  %s %s: %v\n", failure.Kind, failure.Name, failure.Err)
 		}
+	},
+}
 
-		if len(playerpaths) > 0 {
-			for _, path := range playerpaths {
-				os.Mkdir(path, os.ModePerm)
-			}
-		}
+// fetchJob fetches everything for one team or player
+type fetchJob struct {
+	Kind string
+	Name string
+	Run  func() error
+}
 
-		if teams[0] != "" {
-			for _, team := range teams {
+type fetchFailure struct {
+	Kind string
+	Name string
+	Err  error
+}
 
-				team = strings.Trim(team, "[]")
+// runFetchJobs runs jobs on a pool of concurrency workers and returns the
+// failures in job order. Rate limits are enforced by the fetch layer.
+func runFetchJobs(jobs []fetchJob, concurrency int) []fetchFailure {
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
-				params := map[string]string{}
-				
-				params["search"] = string(team)
+	errs := make([]error, len(jobs))
+	indices := make(chan int)
+	var wg sync.WaitGroup
 
-				Data := src.FetchData(sport, "team", params)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = jobs[i].Run()
+			}
+		}()
+	}
+	for i := range jobs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
 
-				if Data == "" {
-					fmt.Println("No data found")
-					return
-				}
+	var failures []fetchFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fetchFailure{Kind: jobs[i].Kind, Name: jobs[i].Name, Err: err})
+		}
+	}
+	return failures
+}
 
-				// Parse the fetched data
-				parsed_data := src.ParseData(Data)
-				if parsed_data == nil {
-					fmt.Println("Error parsing data")
-					return
-				}
+// fetchTeam saves the team data, then fetches the team's player stats and
+// schedule in parallel, or only the games newer than those stored when
+// incremental is set and stats are already stored
+func fetchTeam(store src.Store, provider src.Provider, sport string, year string, team string, incremental bool) error {
+	def, err := src.GetSport(sport)
+	if err != nil {
+		return err
+	}
+	key := src.DataKey{Sport: sport, Season: year, Name: def.TeamDir(team), Kind: "team"}
+
+	params := map[string]string{}
+	params["search"] = team
+	canonical, known := def.Team(team)
+	if known {
+		params["search"] = canonical.Nickname
+	} else {
+		fmt.Printf("%s is not in the %s team table, using the first search result\n", team, sport)
+	}
 
-				direrr := os.MkdirAll("data", os.ModePerm)
-				if direrr != nil {
-					fmt.Printf("Error creating data directory: %v\n", direrr)
-					return
-				}
+	Data, err := src.FetchFromProvider(provider, sport, "team", params)
+	if err != nil {
+		return err
+	}
+	if Data == "" {
+		return fmt.Errorf("no team data found")
+	}
 
-			
-				
-				err := src.SaveToFile(parsed_data, fmt.Sprintf("data/%s/%s/%s",sport, year, string(team)), "team_data.json")
-				if err != nil {
-					fmt.Printf("Error saving team data: %v\n", err)
-					return
-				}
-				fmt.Println("Team data saved successfully.")
+	// Extract team ID
+	teamsData, err := src.DecodeTeams(Data)
+	if err != nil {
+		return err
+	}
+	if len(teamsData) == 0 {
+		return fmt.Errorf("no team found matching %s", team)
+	}
+	if known {
+		teamsData, err = matchTeam(def, canonical, provider.Name(), teamsData)
+		if err != nil {
+			return err
+		}
+	}
 
-				// Prepare and fetch team stats
-				statsParams := map[string]string{}
-				if year != "" {
-					statsParams["season"] = year
-				}
+	if err := store.SaveDocument(key, "team_data.json", Data); err != nil {
+		return fmt.Errorf("error saving team data: %v", err)
+	}
+	if err := store.SaveManifest(src.NewManifest(key.DocumentRef("team_data.json"), requestInput(provider, sport, "team", params, Data))); err != nil {
+		return err
+	}
+	fmt.Printf("Team data saved successfully for %s.\n", team)
 
-				// Extract team ID
-				response, ok := parsed_data["response"].([]interface{})
-				if !ok || len(response) == 0 {
-					fmt.Println("Invalid or missing response data")
-					return
-				}
-				teamData := response[0].(map[string]interface{})
-				idValue, exists := teamData["id"]
-				if !exists {
-					fmt.Println("Team ID key not found in teamData")
-					return
-				}
-				id, ok := idValue.(float64)
-				if !ok {
-					fmt.Printf("Team ID is not a float64, actual type: %T, value: %v\n", idValue, idValue)
-					return
-				}
+	// Prepare and fetch team stats and games
+	statsParams := map[string]string{}
+	if year != "" {
+		statsParams["season"] = year
+	}
+	statsParams["team"] = strconv.Itoa(teamsData[0].ID)
 
-				statsParams["team"] = fmt.Sprintf("%.0f", id)
-				Stats := src.FetchData(sport, "team-stats", statsParams)
-				if Stats == "" {
-					fmt.Println("No stats data found")
-					return
-				}
+	if def.ApiSports.PerGameStats {
+		return fetchTeamIncremental(store, provider, key, teamsData[0].ID, statsParams)
+	}
 
-				// Save the stats data to a JSON file
-				statsParsed := src.ParseData(Stats)
-				if statsParsed == nil {
-					fmt.Println("Error parsing stats data")
-					return
-				}
-				err = src.SaveToFile(statsParsed, fmt.Sprintf("data/%s/%s/%s",sport, year, string(team)), "team_stats.json")
-				if err != nil {
-					fmt.Printf("Error saving team stats: %v\n", err)
-					return
-				}
-				fmt.Println("Team stats saved successfully.")
+	if incremental {
+		stored, err := store.HasGameLines(key)
+		if err != nil {
+			return err
+		}
+		if stored {
+			return fetchTeamIncremental(store, provider, key, teamsData[0].ID, statsParams)
+		}
+		fmt.Printf("No stored stats for %s, fetching full season.\n", team)
+	}
 
-				Games := src.FetchData(sport, "game", statsParams)
-				if Games == "" {
-					fmt.Println("No games data found")
-					return
-				}
+	var wg sync.WaitGroup
+	var statsErr, gamesErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		statsErr = fetchAndSave(store, provider, key, "team-stats", statsParams, key.StatsRef(), store.SaveGameStats)
+	}()
+	go func() {
+		defer wg.Done()
+		gamesErr = fetchAndSave(store, provider, key, "game", statsParams, key.GamesRef(), store.SaveGames)
+	}()
+	wg.Wait()
+
+	return errors.Join(statsErr, gamesErr)
+}
 
-				// Save the stats data to a JSON file
-				gamesParsed := src.ParseData(Games)
-				if gamesParsed == nil {
-					fmt.Println("Error parsing games data")
-					return
-				}
-				err = src.SaveToFile(gamesParsed, fmt.Sprintf("data/%s/%s/%s",sport, year, string(team)), "games.json")
-				if err != nil {
-					fmt.Printf("Error saving games: %v\n", err)
-					return
-				}
-				
-				fmt.Println("Games saved successfully.")
-			}
+// matchTeam moves the search result that is the canonical team to the front,
+// preferring the provider's id and falling back to the team's names
+func matchTeam(def *src.Sport, canonical *src.CanonicalTeam, provider string, teams []src.Team) ([]src.Team, error) {
+	for i, t := range teams {
+		if id, ok := canonical.ProviderIDs[provider]; ok && t.ID == id {
+			return append([]src.Team{teams[i]}, append(teams[:i:i], teams[i+1:]...)...), nil
+		}
+	}
+	for i, t := range teams {
+		if def.SameTeam(t.Name, canonical.Name) || def.SameTeam(t.Code, canonical.ID) {
+			return append([]src.Team{teams[i]}, append(teams[:i:i], teams[i+1:]...)...), nil
 		}
+	}
+	return nil, fmt.Errorf("no search result is the %s", canonical.Name)
+}
 
-		if players[0] != "" {
+// fetchTeamIncremental refreshes the schedule, then fetches player stats only
+// for finished games newer than the latest stored game and merges them in,
+// de-duplicating by game id
+func fetchTeamIncremental(store src.Store, provider src.Provider, key src.DataKey, teamID int, gameParams map[string]string) error {
+	sport := key.Sport
+	def, err := src.GetSport(sport)
+	if err != nil {
+		return err
+	}
 
-			for _, player := range players {
+	body, err := src.FetchFromProvider(provider, sport, "game", gameParams)
+	if err != nil {
+		return fmt.Errorf("game: %v", err)
+	}
+	schedule, err := src.DecodeSchedule(sport, body)
+	if err != nil {
+		return err
+	}
+	if err := store.SaveGames(key, body); err != nil {
+		return fmt.Errorf("error saving games: %v", err)
+	}
+	if err := src.SaveMergedManifest(store, src.NewManifest(key.GamesRef(), requestInput(provider, sport, "game", gameParams, body))); err != nil {
+		return err
+	}
 
-				player = strings.Trim(player, "[]")
+	storedStats, err := store.LoadGameLines(key)
+	if err != nil {
+		return err
+	}
 
-				params := map[string]string{}
+	newGames := src.NewFinishedGames(schedule, storedStats)
+	fmt.Printf("%s: latest stored game %q, %d new finished games\n", key, src.LatestStoredGame(schedule, storedStats), len(newGames))
+	if len(newGames) == 0 {
+		return nil
+	}
 
-				params["search"] = string(player)
-			
+	for _, game := range newGames {
+		params := def.GameStatsParams(game.ID, teamID)
+		body, err := src.FetchFromProvider(provider, sport, "team-stats", params)
+		if err != nil {
+			return fmt.Errorf("team-stats for game %d: %v", game.ID, err)
+		}
+		incoming := src.ParseData(body)
+		if incoming == nil {
+			return fmt.Errorf("team-stats for game %d: error parsing data", game.ID)
+		}
+		incoming["response"] = tagGameEntries(incoming["response"], game.ID)
+		incoming["response"] = filterTeamEntries(incoming["response"], teamID)
+		tagged, err := json.Marshal(incoming)
+		if err != nil {
+			return fmt.Errorf("team-stats for game %d: %v", game.ID, err)
+		}
+		if err := store.SaveGameStats(key, string(tagged)); err != nil {
+			return fmt.Errorf("error saving team stats: %v", err)
+		}
+		if err := src.SaveMergedManifest(store, src.NewManifest(key.StatsRef(), requestInput(provider, sport, "team-stats", params, body))); err != nil {
+			return err
+		}
+	}
 
-				Data := src.FetchData(sport, "player", params)
+	fmt.Printf("Merged %d games into %s\n", len(newGames), key)
+	return nil
+}
 
-				if Data == "" {
-					fmt.Println("No data found")
-					return
-				}
+// tagGameEntries records the game id on per-team entries that do not carry it
+func tagGameEntries(response interface{}, gameID int) []interface{} {
+	entries, _ := response.([]interface{})
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := entry["game"].(map[string]interface{}); !ok {
+			entry["game"] = map[string]interface{}{"id": gameID}
+		}
+	}
+	return entries
+}
 
-				// Parse the fetched data
-				parsed_data := src.ParseData(Data)
-				if parsed_data == nil {
-					fmt.Println("Error parsing data")
-					return
-				}
+// filterTeamEntries keeps the player statistics entries belonging to teamID
+func filterTeamEntries(response interface{}, teamID int) []interface{} {
+	entries, _ := response.([]interface{})
+	kept := make([]interface{}, 0, len(entries))
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		team, ok := entry["team"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := team["id"].(float64); ok && int(id) == teamID {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
 
+// fetchPlayer saves the player data and the player's stats
+func fetchPlayer(store src.Store, provider src.Provider, sport string, year string, player string) error {
+	key := src.DataKey{Sport: sport, Season: year, Name: player, Kind: "player"}
 
-				err := src.SaveToFile(parsed_data, fmt.Sprintf("data/%s/%s/%s",sport, year, string(player)), "player_data.json")
-							
-				if err != nil {
-					fmt.Printf("Error saving player data: %v\n", err)
-					return
-				}
-				fmt.Println("Player data saved successfully.")
+	params := map[string]string{}
+	params["search"] = player
 
-				// Prepare and fetch player stats
-				statsParams := map[string]string{}
-				if year != "" {
-					statsParams["season"] = year
-				}
+	Data, err := src.FetchFromProvider(provider, sport, "player", params)
+	if err != nil {
+		return err
+	}
+	if Data == "" {
+		return fmt.Errorf("no player data found")
+	}
 
-				// Extract player ID
-				response, ok := parsed_data["response"].([]interface{})
-				if !ok || len(response) == 0 {
-					fmt.Println("Invalid or missing response data")
-					return
-				}
-				playerData := response[0].(map[string]interface{})
-				idValue, exists := playerData["id"]
-				if !exists {
-					fmt.Println("Player ID key not found in playerData")
-					return
-				}
-				id, ok := idValue.(float64)
-				if !ok {
-					fmt.Printf("Player ID is not a float64, actual type: %T, value: %v\n", idValue, idValue)
-					return
-				}
+	// Extract player ID
+	playersData, err := src.DecodePlayers(Data)
+	if err != nil {
+		return err
+	}
+	if len(playersData) == 0 {
+		return fmt.Errorf("no player found matching %s", player)
+	}
 
-				statsParams["id"] = fmt.Sprintf("%.0f", id)
-				Stats := src.FetchData(sport, "player-stats", statsParams)
-				if Stats == "" {
-					fmt.Println("No stats data found")
-					return
-				}
+	if err := store.SaveDocument(key, "player_data.json", Data); err != nil {
+		return fmt.Errorf("error saving player data: %v", err)
+	}
+	if err := store.SaveManifest(src.NewManifest(key.DocumentRef("player_data.json"), requestInput(provider, sport, "player", params, Data))); err != nil {
+		return err
+	}
+	fmt.Printf("Player data saved successfully for %s.\n", player)
 
-				// Save the stats data to a JSON file
-				statsParsed := src.ParseData(Stats)
-				if statsParsed == nil {
-					fmt.Println("Error parsing stats data")
-					return
-				}
-				err = src.SaveToFile(statsParsed, fmt.Sprintf("data/%s/%s/%s",sport, year, string(player)), "player_stats.json")
-				if err != nil {
-					fmt.Printf("Error saving player stats: %v\n", err)
-					return
-				}
-				fmt.Println("Player stats saved successfully.")
-			
-		}
+	// Prepare and fetch player stats
+	statsParams := map[string]string{}
+	if year != "" {
+		statsParams["season"] = year
 	}
-},
+	statsParams["id"] = strconv.Itoa(playersData[0].ID)
+
+	return fetchAndSave(store, provider, key, "player-stats", statsParams, key.StatsRef(), store.SaveGameStats)
+}
+
+// fetchAndSave fetches one request type, hands the response to save and
+// records it in the manifest of the output ref
+func fetchAndSave(store src.Store, provider src.Provider, key src.DataKey, requestType string, params map[string]string, ref string, save func(src.DataKey, string) error) error {
+	body, err := src.FetchFromProvider(provider, key.Sport, requestType, params)
+	if err != nil {
+		return fmt.Errorf("%s: %v", requestType, err)
+	}
+	if body == "" {
+		return fmt.Errorf("%s: no data found", requestType)
+	}
+
+	if err := save(key, body); err != nil {
+		return fmt.Errorf("%s: error saving: %v", requestType, err)
+	}
+	if err := src.SaveMergedManifest(store, src.NewManifest(ref, requestInput(provider, key.Sport, requestType, params, body))); err != nil {
+		return fmt.Errorf("%s: %v", requestType, err)
+	}
+	fmt.Printf("Saved %s for %s\n", requestType, key)
+	return nil
+}
+
+// requestInput is the manifest input of a provider response
+func requestInput(provider src.Provider, sport string, requestType string, params map[string]string, body string) src.ManifestInput {
+	return src.InputOfRaw(src.RequestRef(provider.Name(), sport, requestType, params), body)
 }
 
 var FetchOddsCmd = &cobra.Command{
 	Use:   "fetchodds",
 	Short: "Fetch odds data",
-	Long:  `Fetch odds data from the internet`,
+	Long: `Fetch odds data from the internet for one date (-d), a date range
+(--from/--to) or a whole season (--season). Ranged fetches only request dates
+that appear in the stored games.json schedules, falling back to every day of
+the range when no schedule is stored, and resume where an interrupted run
+stopped unless --restart is given.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-			// Retrieve the date flag value
-			date := cmd.Flag("date").Value.String()
+		sport := cmd.Flag("sport").Value.String()
+		date := cmd.Flag("date").Value.String()
+		from := cmd.Flag("from").Value.String()
+		to := cmd.Flag("to").Value.String()
+		season := cmd.Flag("season").Value.String()
+
+		specs, err := src.ParseSnapshotSpecs(cmd.Flag("snapshots").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		store, err := src.OpenStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer store.Close()
 
-			// Split the date by "-" into year, month, day
-			dateArr := strings.Split(date, "-")
-			if len(dateArr) != 3 {
-					fmt.Println("Error: Date must be in the format YYYY-MM-DD")
+		dates, err := oddsDates(store, sport, date, from, to, season)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(dates) == 0 {
+			fmt.Println("No game dates to fetch")
+			return
+		}
+
+		provider, err := src.GetProvider(cmd.Flag("provider").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := setupCassette(cmd); err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer func() { fmt.Println(src.QuotaReport(provider.Name())) }()
+
+		// Single dates are always refetched; ranges resume
+		ranged := date == ""
+		var progress *src.FetchProgress
+		if ranged {
+			progress, err = src.LoadFetchProgress(sport, "fetchodds")
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if restart, _ := cmd.Flags().GetBool("restart"); restart {
+				if err := progress.Reset(); err != nil {
+					fmt.Println(err)
 					return
+				}
 			}
+		}
 
-			// Initialize a slice with length 3 to hold year, month, day as integers
-			intDateArr := make([]int, 3)
-
-			// Convert each part of the date to an integer
-			for i, v := range dateArr {
-					intValue, err := strconv.Atoi(v)
-					if err != nil {
-							fmt.Printf("Error converting %s to int: %v\n", v, err)
-							return
-					}
-					intDateArr[i] = intValue
+		for i, d := range dates {
+			if ranged && progress.Completed[d] {
+				fmt.Printf("Skipping %s, already fetched\n", d)
+				continue
 			}
+			fmt.Printf("Fetching odds for %s (%d/%d)\n", d, i+1, len(dates))
+			if err := fetchOddsForDate(store, provider, sport, d, specs, ranged); err != nil {
+				fmt.Printf("Stopped at %s: %v\n", d, err)
+				return
+			}
+			if ranged {
+				if err := progress.MarkDone(d); err != nil {
+					fmt.Println(err)
+					return
+				}
+			}
+		}
+	},
+}
 
-			// Create a time.Time object from the integer date components
-			dateObj := time.Date(
-					intDateArr[0],
-					time.Month(intDateArr[1]),
-					intDateArr[2],
-					0, 0, 0, 0,
-					time.UTC,
-			)
+// oddsDates resolves the -d, --from/--to and --season flags to a list of
+// YYYY-MM-DD dates
+func oddsDates(store src.Store, sport string, date string, from string, to string, season string) ([]string, error) {
+	if date != "" {
+		if from != "" || to != "" || season != "" {
+			return nil, fmt.Errorf("-d cannot be combined with --from, --to or --season")
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("date must be in the format YYYY-MM-DD")
+		}
+		return []string{date}, nil
+	}
 
-			// Format the date in RFC3339 format
-			formattedDate := dateObj.UTC().Format(time.RFC3339)
+	if from == "" && to == "" && season == "" {
+		return nil, fmt.Errorf("one of -d, --from/--to or --season is required")
+	}
+	if (from == "") != (to == "") && season == "" {
+		return nil, fmt.Errorf("--from and --to must be given together")
+	}
 
-			sport := cmd.Flag("sport").Value.String()
+	var fromDate time.Time
+	if from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --from date %q, expected YYYY-MM-DD", from)
+		}
+		fromDate = t
+	}
+	if to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --to date %q, expected YYYY-MM-DD", to)
+		}
+		if from != "" && t.Before(fromDate) {
+			return nil, fmt.Errorf("--to %s is before --from %s", to, from)
+		}
+	}
 
-			// Fetch and parse odds data
-			odds := src.FetchGames(formattedDate, sport)
-			parsedOdds := src.ParseData(odds)
+	if season == "" {
+		season = strconv.Itoa(fromDate.Year())
+	}
 
-			// Retrieve the "data" key from parsed_odds
-			gamesInterface, ok := parsedOdds["data"].([]interface{})
-			if !ok {
-					fmt.Println("Invalid or missing game data")
-					return
-			}	
+	dates, err := src.ScheduleDates(store, sport, season, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(dates) > 0 {
+		return dates, nil
+	}
+	if from == "" {
+		return nil, fmt.Errorf("no stored schedule for %s %s; run fetchdata first or pass --from/--to", sport, season)
+	}
 
-			for _, game := range gamesInterface {
-				gameMap, ok := game.(map[string]interface{})
-				if !ok {
-					fmt.Println("Invalid game data")
-					return
-				}
+	fmt.Printf("No stored schedule for %s %s, checking every day from %s to %s\n", sport, season, from, to)
+	return src.DateRange(from, to)
+}
+
+// fetchOddsForDate fetches the events of one date and saves each event's
+// midnight snapshot as its primary odds plus any extra snapshots to its
+// history. With skipExisting, snapshots already stored are not refetched, so
+// a date interrupted halfway resumes cheaply.
+func fetchOddsForDate(store src.Store, provider src.Provider, sport string, date string, specs []src.SnapshotSpec, skipExisting bool) error {
+	def, err := src.GetSport(sport)
+	if err != nil {
+		return err
+	}
+
+	dateObj, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return fmt.Errorf("date must be in the format YYYY-MM-DD")
+	}
+
+	// Format the date in RFC3339 format
+	formattedDate := dateObj.UTC().Format(time.RFC3339)
+
+	// Fetch and parse odds data
+	odds, err := provider.OddsEvents(sport, formattedDate)
+	if err != nil {
+		return err
+	}
+	events, err := src.DecodeOddsEvents(odds)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events.Data {
+		key, err := resolveOddsEvent(store, sport, date, def.EventDir(event.AwayTeam, event.HomeTeam), event.AwayTeam+"_"+event.HomeTeam)
+		if err != nil {
+			return err
+		}
+
+		stored := false
+		if skipExisting {
+			if stored, err = store.HasOdds(key, ""); err != nil {
+				return err
+			}
+		}
+		if !stored {
+			if err := fetchSnapshot(store, provider, sport, event, formattedDate, key, "day", true); err != nil {
+				return err
+			}
+		}
 
-				odds := src.FetchOdds(formattedDate, sport, gameMap["id"].(string))
-				parsedOdds := src.ParseData(odds)
-				err := src.SaveToFile(parsedOdds, fmt.Sprintf("data/%s/%s/%s/%s", sport, dateArr[0], date, gameMap["away_team"].(string)+"_"+gameMap["home_team"].(string)), "odds.json")
+		if len(specs) == 0 {
+			continue
+		}
+		commence, err := src.ParseTime(event.CommenceTime)
+		if err != nil {
+			fmt.Printf("Skipping snapshots for %s @ %s: %v\n", event.AwayTeam, event.HomeTeam, err)
+			continue
+		}
+		for _, spec := range specs {
+			if skipExisting {
+				stored, err := store.HasOdds(key, spec.Label)
 				if err != nil {
-					fmt.Printf("Error saving odds data: %v\n", err)
-					return
+					return err
+				}
+				if stored {
+					continue
 				}
 			}
-	},
+			at := spec.Time(commence).Format(time.RFC3339)
+			if err := fetchSnapshot(store, provider, sport, event, at, key, spec.Label, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fetchSnapshot fetches an event's odds as of at and stores it in the
+// event's history, and as its primary odds when primary is set. A snapshot
+// that cannot be decoded is an error, so a ranged fetch does not record its
+// date as done.
+func fetchSnapshot(store src.Store, provider src.Provider, sport string, event src.OddsEvent, at string, key src.EventKey, label string, primary bool) error {
+	odds, err := provider.EventOdds(sport, at, event.ID)
+	if err != nil {
+		return err
+	}
+	snapshot, err := src.DecodeEventOdds(odds)
+	if err != nil {
+		return fmt.Errorf("decoding %s snapshot of %s @ %s: %v", label, event.AwayTeam, event.HomeTeam, err)
+	}
+
+	timestamp, err := src.ParseTime(snapshot.Timestamp)
+	if err != nil {
+		timestamp, _ = src.ParseTime(at)
+	}
+	if err := store.SaveOdds(key, label, timestamp, odds, primary); err != nil {
+		return err
+	}
+
+	input := src.InputOfRaw(src.RequestRef(provider.Name(), sport, "odds", map[string]string{"event": event.ID, "date": at}), odds)
+	if err := store.SaveManifest(src.NewManifest(key.SnapshotRef(label, timestamp), input)); err != nil {
+		return err
+	}
+	if primary {
+		return store.SaveManifest(src.NewManifest(key.OddsRef(), input))
+	}
+	return nil
+}
+
+// resolveOddsEvent returns the event named by team slugs, unless odds of
+// the event from before the team table (full team names, with or without
+// the underscores arbitrage renames them to) are already stored
+func resolveOddsEvent(store src.Store, sport string, date string, name string, legacy string) (src.EventKey, error) {
+	for _, old := range []string{strings.ReplaceAll(legacy, " ", "_"), legacy} {
+		key := src.EventKey{Sport: sport, Date: date, Event: old}
+		stored, err := store.HasOdds(key, "")
+		if err != nil {
+			return key, err
+		}
+		if stored {
+			return key, nil
+		}
+	}
+	return src.EventKey{Sport: sport, Date: date, Event: name}, nil
 }
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"betterbetter/src"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var AsJSON bool
+
+	inspectCmd.Flags().BoolVar(&AsJSON, "json", false, "Print the lineage as JSON")
+	rootCmd.AddCommand(inspectCmd)
+}
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <output>",
+	Short: "Show how an output was produced",
+	Long: `Show the manifest of an output such as data/bets.json,
+data/nba/2024/2024-11-01/arbitrage.json or a *_preds.json file: the command,
+flags, version and time that produced it and the content hash of every input,
+followed by the manifests of those inputs back to the provider requests.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := src.OpenStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer store.Close()
+
+		path := args[0]
+		manifest, ok, err := store.LoadManifest(src.RefFromPath(path))
+		if err == nil && !ok {
+			// Reports such as clv.json live outside the store
+			manifest, ok, err = src.LoadFileManifest(path)
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if !ok {
+			fmt.Printf("No manifest recorded for %s\n", path)
+			return
+		}
+
+		lineage, err := src.TraceLineage(store, manifest)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			encoded, err := json.MarshalIndent(lineage, "", "  ")
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Println(string(encoded))
+			return
+		}
+		printLineage(lineage, 0)
+	},
+}
+
+func printLineage(l *src.Lineage, depth int) {
+	indent := strings.Repeat("    ", depth)
+	m := l.Manifest
+	fmt.Printf("%s%s\n", indent, m.Output)
+	fmt.Printf("%s  written %s by %s (%s, %s storage)\n", indent, m.WrittenAt, m.Command, m.Version, m.Storage)
+	fmt.Printf("%s  run     betterbetter %s\n", indent, strings.Join(m.Args, " "))
+
+	params := make([]string, 0, len(m.Params))
+	for _, name := range src.SortedKeys(m.Params) {
+		params = append(params, name+"="+m.Params[name])
+	}
+	fmt.Printf("%s  params  %s\n", indent, strings.Join(params, " "))
+
+	fmt.Printf("%s  inputs\n", indent)
+	for _, in := range m.Inputs {
+		hash := in.SHA256
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		fmt.Printf("%s    %-12s %s\n", indent, hash, in.Ref)
+	}
+	if len(m.Diagnostics) > 0 {
+		fmt.Printf("%s  diagnostics\n", indent)
+	}
+	for _, metric := range src.SortedKeys(m.Diagnostics) {
+		status := "passed"
+		if problems := m.Diagnostics[metric].Problems; len(problems) > 0 {
+			status = "flagged: " + strings.Join(problems, "; ")
+		}
+		fmt.Printf("%s    %-12s %s\n", indent, metric, status)
+	}
+	for _, ref := range src.SortedKeys(l.Inputs) {
+		printLineage(l.Inputs[ref], depth+1)
+	}
+}
@@ -2,17 +2,19 @@ package cmd
 
 import (
 	"betterbetter/src"
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
 func init() {
 
-  var RiskReward float64
+	var RiskReward float64
 	var MaxBets int
 
-  betCMD.Flags().Float64VarP(&RiskReward, "rr", "r", 1, "Risk Reward Ratio")
+	betCMD.Flags().Float64VarP(&RiskReward, "rr", "r", 1, "Risk Reward Ratio")
 	betCMD.Flags().IntVarP(&MaxBets, "maxbets", "m", 3, "Max number of bets to make")
-  rootCmd.AddCommand(betCMD)
+	rootCmd.AddCommand(betCMD)
 }
 
 var betCMD = &cobra.Command{
@@ -28,9 +30,15 @@ var betCMD = &cobra.Command{
 		if err != nil {
 			panic(err)
 		}
-		bets := src.MakeBets(rr, maxbets)
+		store, err := src.OpenStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer store.Close()
 
-		//save bets to file
-		src.SaveToFile(bets,"data","bets.json")
+		if _, err := src.MakeBets(store, rr, maxbets); err != nil {
+			fmt.Println(err)
+		}
 	},
-}
\ No newline at end of file
+}
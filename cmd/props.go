@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"betterbetter/src"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var Sport string
+	var Player string
+	var Market string
+	var Bookmaker string
+	var From string
+	var To string
+	var AllSnapshots bool
+
+	propsCmd.Flags().StringVarP(&Sport, "sport", "s", "", "Sport to search (default every sport)")
+	propsCmd.Flags().StringVarP(&Player, "player", "p", "", "Player name, matched ignoring case, accents and suffixes")
+	propsCmd.Flags().StringVarP(&Market, "market", "m", "", "Odds market key, e.g. player_points")
+	propsCmd.Flags().StringVarP(&Bookmaker, "bookmaker", "b", "", "Bookmaker key, e.g. draftkings")
+	propsCmd.Flags().StringVar(&From, "from", "", "First YYYY-MM-DD game date")
+	propsCmd.Flags().StringVar(&To, "to", "", "Last YYYY-MM-DD game date")
+	propsCmd.Flags().BoolVar(&AllSnapshots, "all-snapshots", false, "Include every stored snapshot, not only the primary odds")
+
+	rootCmd.AddCommand(propsCmd)
+}
+
+var propsCmd = &cobra.Command{
+	Use:   "props",
+	Short: "Search stored player prop lines",
+	Long: `List stored player prop lines filtered by sport, player, market,
+bookmaker and game dates, e.g. every Tatum points line in November:
+
+  betterbetter props -s nba -p "Jayson Tatum" -m player_points --from 2024-11-01 --to 2024-11-30`,
+	Run: func(cmd *cobra.Command, args []string) {
+		allSnapshots, err := cmd.Flags().GetBool("all-snapshots")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		query := src.PropQuery{
+			Sport:        cmd.Flag("sport").Value.String(),
+			Player:       cmd.Flag("player").Value.String(),
+			Market:       cmd.Flag("market").Value.String(),
+			Bookmaker:    cmd.Flag("bookmaker").Value.String(),
+			From:         cmd.Flag("from").Value.String(),
+			To:           cmd.Flag("to").Value.String(),
+			AllSnapshots: allSnapshots,
+		}
+
+		store, err := src.OpenStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer store.Close()
+
+		lines, err := store.PropOdds(query)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		for _, l := range lines {
+			fmt.Printf("%-20s %-22s %-25s %-16s %-5s %6v %7.2f  %s %s\n",
+				l.Time, l.AwayTeam+" @ "+l.HomeTeam, l.Description, l.Market, l.Name, pointString(l.Point), l.Price, l.Bookmaker, l.Label)
+		}
+		fmt.Printf("%d lines\n", len(lines))
+	},
+}
@@ -1,10 +1,18 @@
 package cmd
 
 import (
+	"betterbetter/src"
 	"os"
+	"strconv"
+	"time"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+var configPath string
+var seed uint64
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "betterbetter",
@@ -15,8 +23,61 @@ examples and usage of using your application. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := src.LoadConfig(configPath)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Apply(); err != nil {
+			return err
+		}
+		if err := applyCommandDefaults(cmd, cfg.CommandDefaults(cmd.Name())); err != nil {
+			return err
+		}
+		if err := applySeed(cmd); err != nil {
+			return err
+		}
+		src.StartRun(cmd.Name(), os.Args[1:], flagValues(cmd))
+		return nil
+	},
+}
+
+// flagValues returns the value of every flag of cmd, set or defaulted, for
+// run manifests
+func flagValues(cmd *cobra.Command) map[string]string {
+	values := map[string]string{}
+	record := func(f *pflag.Flag) { values[f.Name] = f.Value.String() }
+	cmd.Flags().VisitAll(record)
+	cmd.InheritedFlags().VisitAll(record)
+	return values
 }
 
+// applyCommandDefaults sets configured values for flags the user did not pass
+func applyCommandDefaults(cmd *cobra.Command, defaults map[string]string) error {
+	for name, value := range defaults {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySeed picks a seed when none was given, recording it in the flag so
+// manifests show how to repeat the run
+func applySeed(cmd *cobra.Command) error {
+	flag := cmd.Flags().Lookup("seed")
+	if flag != nil && !flag.Changed {
+		if err := cmd.Flags().Set("seed", strconv.FormatUint(uint64(time.Now().UnixNano()), 10)); err != nil {
+			return err
+		}
+	}
+	src.Seed = seed
+	return nil
+}
 
 func Execute() {
 	err := rootCmd.Execute()
@@ -25,7 +86,7 @@ func Execute() {
 	}
 }
 
-func init() {	
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default "+src.DefaultConfigFile+")")
+	rootCmd.PersistentFlags().Uint64Var(&seed, "seed", 0, "Seed for every random draw; runs with the same seed give identical results (default random)")
 }
-
-
@@ -0,0 +1,224 @@
+package src
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Settings of the adaptive Metropolis sampler, following Haario, Saksman and
+// Tamminen (2001): the proposal covariance is the running covariance of the
+// warmup draws scaled by 2.38^2/d, frozen once warmup ends
+const (
+	adaptiveInitialScale = 0.1  // proposal sd of each coordinate before adapting
+	adaptiveEpsilon      = 1e-6 // added to the diagonal to keep the covariance positive definite
+)
+
+// AdaptiveMetropolis samples in continuous parameter space, without a grid.
+// Each parameter is moved on the real line through the transform onto its
+// prior's support. The first warmup of numsteps iterations tune the proposal
+// and are discarded; it returns the remaining draws and their negative log
+// likelihoods.
+func (m *MarkovChain) AdaptiveMetropolis(numsteps int, warmup int) ([][]float64, []float64) {
+	if warmup >= numsteps {
+		panic("Warmup period must be less than the total number of steps")
+	}
+
+	dims := len(m.Distributions)
+	transforms, logTarget := m.unconstrainedTarget()
+
+	current := unconstrain(transforms, m.initialPoint())
+	currentLogTarget, currentNLL := logTarget(current)
+
+	samples := make([][]float64, numsteps+1)
+	likelihoods := make([]float64, numsteps+1)
+	samples[0] = constrain(transforms, current)
+	likelihoods[0] = currentNLL
+
+	// Running mean and covariance of the warmup draws
+	mean := append([]float64{}, current...)
+	cov := mat.NewSymDense(dims, nil)
+	proposal := mat.NewSymDense(dims, nil)
+	for i := 0; i < dims; i++ {
+		proposal.SetSym(i, i, adaptiveInitialScale*adaptiveInitialScale)
+	}
+	var chol mat.Cholesky
+	chol.Factorize(proposal)
+	adaptStart := max(warmup/10, 2*dims)
+	scale := 2.38 * 2.38 / float64(dims)
+
+	z := make([]float64, dims)
+	for i := 1; i <= numsteps; i++ {
+		// Propose y' = y + L z with L the Cholesky factor of the proposal
+		for j := range z {
+			z[j] = m.rng().NormFloat64()
+		}
+		var l mat.TriDense
+		chol.LTo(&l)
+		step := mat.NewVecDense(dims, nil)
+		step.MulVec(&l, mat.NewVecDense(dims, z))
+		proposed := make([]float64, dims)
+		for j := range proposed {
+			proposed[j] = current[j] + step.AtVec(j)
+		}
+
+		proposedLogTarget, proposedNLL := logTarget(proposed)
+		if math.Log(m.rng().Float64()) < proposedLogTarget-currentLogTarget {
+			current = proposed
+			currentLogTarget = proposedLogTarget
+			currentNLL = proposedNLL
+		}
+		samples[i] = constrain(transforms, current)
+		likelihoods[i] = currentNLL
+
+		if i > warmup {
+			continue
+		}
+
+		// Welford update of the running mean and covariance
+		n := float64(i + 1)
+		delta := make([]float64, dims)
+		for j := range mean {
+			delta[j] = current[j] - mean[j]
+			mean[j] += delta[j] / n
+		}
+		for j := 0; j < dims; j++ {
+			for k := j; k < dims; k++ {
+				c := cov.At(j, k)
+				cov.SetSym(j, k, c+(delta[j]*(current[k]-mean[k])-c)/n)
+			}
+		}
+
+		if i >= adaptStart {
+			for j := 0; j < dims; j++ {
+				for k := j; k < dims; k++ {
+					v := scale * cov.At(j, k)
+					if j == k {
+						v += scale * adaptiveEpsilon
+					}
+					proposal.SetSym(j, k, v)
+				}
+			}
+			var next mat.Cholesky
+			if next.Factorize(proposal) {
+				chol = next
+			}
+		}
+	}
+
+	m.Stats = SamplerStats{Proposal: proposal}
+
+	// Discard warmup samples
+	return samples[warmup:], likelihoods[warmup:]
+}
+
+// unconstrainedTarget returns the transform of every parameter and the log
+// posterior density in unconstrained space, with the negative log likelihood
+// of the matching point; points outside the support have density zero
+func (m *MarkovChain) unconstrainedTarget() ([]Transform, func([]float64) (float64, float64)) {
+	transforms := make([]Transform, len(m.Distributions))
+	for i, prior := range m.Distributions {
+		transforms[i] = TransformFor(prior)
+	}
+
+	logTarget := func(y []float64) (float64, float64) {
+		x := constrain(transforms, y)
+		logPrior := LogPrior(m.Distributions, x)
+		if math.IsInf(logPrior, -1) || math.IsNaN(logPrior) {
+			return math.Inf(-1), math.Inf(1)
+		}
+		m.Likelihood.Params = x
+		nll := m.Likelihood.CalcDataLikelihood()
+		if math.IsNaN(nll) || math.IsInf(nll, 0) {
+			return math.Inf(-1), math.Inf(1)
+		}
+		logJacobian := 0.0
+		for i, t := range transforms {
+			logJacobian += t.LogJacobian(y[i])
+		}
+		return -nll + logPrior + logJacobian, nll
+	}
+	return transforms, logTarget
+}
+
+// unconstrainedGradient is the gradient of the log density returned by
+// unconstrainedTarget at y, exact when the likelihood and every prior have
+// analytic derivatives
+func (m *MarkovChain) unconstrainedGradient(transforms []Transform, logTarget func([]float64) (float64, float64), y []float64) []float64 {
+	priorGrads := make([]float64, len(y))
+	exact := m.Likelihood.HasGradient()
+	x := constrain(transforms, y)
+	for i, prior := range m.Distributions {
+		if !exact {
+			break
+		}
+		priorGrads[i], exact = prior.DLogProb(x[i])
+	}
+
+	if !exact {
+		grad := gradient(func(q []float64) float64 {
+			lp, _ := logTarget(q)
+			return -lp
+		}, y)
+		for i := range grad {
+			grad[i] = -grad[i]
+		}
+		return grad
+	}
+
+	m.Likelihood.Params = x
+	_, nllGrad := m.Likelihood.Gradient()
+	grad := make([]float64, len(y))
+	for i, t := range transforms {
+		grad[i] = (priorGrads[i]-nllGrad[i])*t.DConstrain(y[i]) + t.DLogJacobian(y[i])
+	}
+	return grad
+}
+
+// initialPoint draws a starting point from the priors that has a finite
+// likelihood, falling back to the last draw
+func (m *MarkovChain) initialPoint() []float64 {
+	point := make([]float64, len(m.Distributions))
+	for attempt := 0; attempt < 100; attempt++ {
+		for i, prior := range m.Distributions {
+			point[i] = prior.CreateDist(m.rng()).Rand()
+		}
+		if math.IsInf(LogPrior(m.Distributions, point), -1) || !interior(m.Distributions, point) {
+			continue
+		}
+		m.Likelihood.Params = point
+		nll := m.Likelihood.CalcDataLikelihood()
+		if !math.IsNaN(nll) && !math.IsInf(nll, 0) {
+			break
+		}
+	}
+	return point
+}
+
+// interior reports whether point is strictly inside the support of priors,
+// where the transforms are finite
+func interior(priors []DistributionParams, point []float64) bool {
+	for i, prior := range priors {
+		y := TransformFor(prior).Unconstrain(point[i])
+		if math.IsInf(y, 0) || math.IsNaN(y) {
+			return false
+		}
+	}
+	return true
+}
+
+func constrain(transforms []Transform, y []float64) []float64 {
+	x := make([]float64, len(y))
+	for i, t := range transforms {
+		x[i] = t.Constrain(y[i])
+	}
+	return x
+}
+
+func unconstrain(transforms []Transform, x []float64) []float64 {
+	y := make([]float64, len(x))
+	for i, t := range transforms {
+		y[i] = t.Unconstrain(x[i])
+	}
+	return y
+}
@@ -0,0 +1,81 @@
+package src
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// priorChain samples the priors alone: the likelihood sets no parameters and
+// adds nothing to the target
+func priorChain(priors []DistributionParams, seed uint64) MarkovChain {
+	likelihood := Likelihood{
+		Params:             make([]float64, len(priors)),
+		DistributionParams: DistributionParams{Dist: "None", Params: map[string]float64{}},
+		InputData:          *mat.NewDense(1, 1, []float64{0}),
+		OutputData:         *mat.NewVecDense(1, []float64{0}),
+		Link:               func(_ []float64, _ []float64) []float64 { return nil },
+	}
+	return MarkovChain{
+		Distributions: priors,
+		Likelihood:    likelihood,
+		Sampler:       "Adaptive",
+		Rand:          NewRand(seed),
+	}
+}
+
+func TestAdaptiveMetropolisLearnsGaussianCovariance(t *testing.T) {
+	sds := []float64{2, 0.5}
+	m := priorChain([]DistributionParams{
+		{Dist: "Normal", Params: map[string]float64{"Mu": 1, "Sigma": sds[0]}},
+		{Dist: "Normal", Params: map[string]float64{"Mu": -3, "Sigma": sds[1]}},
+	}, 11)
+	samples, _ := m.AdaptiveMetropolis(30000, 20000)
+
+	// The adapted proposal is the target covariance scaled by 2.38^2/d
+	scale := 2.38 * 2.38 / 2
+	proposal := m.Stats.Proposal
+	if proposal == nil {
+		t.Fatal("no adapted proposal recorded")
+	}
+	for i, sd := range sds {
+		want := scale * sd * sd
+		if got := proposal.At(i, i); math.Abs(got-want) > 0.15*want {
+			t.Errorf("proposal variance %d = %.4f, want %.4f", i, got, want)
+		}
+	}
+	if got := proposal.At(0, 1); math.Abs(got) > 0.1*scale*sds[0]*sds[1] {
+		t.Errorf("proposal covariance of independent parameters = %.4f, want about 0", got)
+	}
+
+	for i, want := range []float64{1, -3} {
+		mean := 0.0
+		for _, s := range samples {
+			mean += s[i]
+		}
+		mean /= float64(len(samples))
+		if math.Abs(mean-want) > 0.2*sds[i] {
+			t.Errorf("mean of param %d = %.3f, want %.1f", i, mean, want)
+		}
+	}
+}
+
+func TestAdaptiveMetropolisStaysInSupport(t *testing.T) {
+	// Mostly zeros put the Gamma(2, 5) posterior of the rate near zero
+	data := []float64{0, 0, 1, 0}
+	m := poissonChain(data, 1, 1, 5)
+	samples, _ := m.AdaptiveMetropolis(6000, 2000)
+
+	mean := 0.0
+	for _, s := range samples {
+		if !(s[0] > 0) {
+			t.Fatalf("rate %v drawn outside its positive support", s[0])
+		}
+		mean += s[0]
+	}
+	mean /= float64(len(samples))
+	if want := 2.0 / 5.0; math.Abs(mean-want) > 0.1*want {
+		t.Errorf("posterior mean rate = %.3f, want %.3f", mean, want)
+	}
+}
@@ -0,0 +1,99 @@
+package src
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ApiSports serves teams, players, player statistics and games from the
+// api-sports.io family of APIs. Endpoints come from the sport definitions;
+// BaseURL overrides them for every sport and BaseURLs per sport.
+type ApiSports struct {
+	Key      string
+	BaseURL  string
+	BaseURLs map[string]string
+}
+
+func NewApiSports() *ApiSports {
+	return &ApiSports{
+		BaseURLs: map[string]string{},
+	}
+}
+
+func (a *ApiSports) Name() string {
+	return "api-sports"
+}
+
+// Configure sets the rapidapi key and overrides the base URL of every sport
+// and of single sports
+func (a *ApiSports) Configure(cfg ProviderConfig) error {
+	if cfg.Key != "" {
+		a.Key = cfg.Key
+	}
+	if cfg.BaseURL != "" {
+		a.BaseURL = cfg.BaseURL
+	}
+	for sport, base := range cfg.BaseURLs {
+		a.BaseURLs[sport] = base
+	}
+	return nil
+}
+
+func (a *ApiSports) Teams(sport string, args map[string]string) (string, error) {
+	return a.get(sport, "/teams", args)
+}
+
+func (a *ApiSports) Players(sport string, args map[string]string) (string, error) {
+	return a.get(sport, "/players", args)
+}
+
+// PlayerGameStats serves per-game player lines from the sport's stats_path
+func (a *ApiSports) PlayerGameStats(sport string, args map[string]string) (string, error) {
+	def, err := GetSport(sport)
+	if err != nil {
+		return "", fmt.Errorf("api-sports: %v", err)
+	}
+	return a.get(sport, def.ApiSports.StatsPath, args)
+}
+
+func (a *ApiSports) Games(sport string, args map[string]string) (string, error) {
+	return a.get(sport, "/games", args)
+}
+
+func (a *ApiSports) OddsEvents(sport string, date string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (a *ApiSports) EventOdds(sport string, date string, eventID string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (a *ApiSports) get(sport string, path string, args map[string]string) (string, error) {
+	base, ok := a.BaseURLs[sport]
+	if !ok && a.BaseURL != "" {
+		base, ok = a.BaseURL, true
+	}
+	if !ok {
+		def, err := GetSport(sport)
+		if err != nil || def.ApiSports.BaseURL == "" {
+			return "", fmt.Errorf("api-sports: unsupported sport %q", sport)
+		}
+		base = def.ApiSports.BaseURL
+	}
+
+	if err := requireKey(a.Name(), a.Key); err != nil {
+		return "", err
+	}
+
+	host, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("api-sports: invalid base url %q: %v", base, err)
+	}
+
+	headers := map[string]string{
+		"x-rapidapi-key":  a.Key,
+		"x-rapidapi-host": host.Host,
+	}
+
+	return httpGet(a.Name(), base+path+encodeQuery(args), headers)
+}
@@ -7,327 +7,191 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"sort"
 	"strings"
 )
 
-// Removed the ArbitrageResult struct since we no longer need it.
+// Arbitrage compares predicted distributions against every player prop in
+// the odds under oddspath and stores the results as that scope's arbitrage
+// results. at picks the line to compare against from each event's snapshot
+// history (see ParseSnapshotSpec); empty uses the primary snapshot. Markets
+// come from the definition of the sport named in oddspath, or of sport when
+// the path names none. Players are matched to sportsbook names through the
+// sport's alias table and fuzzy matching; the matches are stored as the
+// player_matches.json document of the predictions' key. When statspath lies
+// under a team directory only that team's games are priced.
+func Arbitrage(store Store, sport string, statspath string, oddspath string, at string) []ArbitrageResult {
+	results := make([]ArbitrageResult, 0)
+
+	if fromPath, err := SportFromPath(oddspath); err == nil {
+		sport = fromPath
+	}
+	def, err := GetSport(sport)
+	if err != nil {
+		fmt.Println(err)
+		return results
+	}
 
-// Arbitrage now returns a slice of maps
-func Arbitrage(statspath string, oddspath string) []map[string]any {
-	results := make([]map[string]any, 0)
+	scope, err := OddsScopeFromPath(oddspath)
+	if err != nil {
+		fmt.Println(err)
+		return results
+	}
+	scope.Sport = sport
+	key, err := DataKeyFromPath(statspath)
+	if err != nil {
+		fmt.Println(err)
+		return results
+	}
 
-	oddsMap := make(map[string][]map[string]any)
-	// Read odds from JSON files
-	oddsData := ReadOdds(oddspath)
+	oddsMap := make(map[string][]BetOutcome)
+	oddsData, oddsRefs, err := OddsAtSpec(store, scope, at)
+	if err != nil {
+		fmt.Println(err)
+		return results
+	}
 
-	fmt.Println(oddsData)
-	// extract odds and store in oddsMap
-	for _, d := range oddsData {
-		data := d.(map[string]interface{})
-		gameData := data["data"].(map[string]interface{})
-		time := gameData["commence_time"]
-		homeTeam := gameData["home_team"]
-		awayTeam := gameData["away_team"]
-
-		bookmakers := gameData["bookmakers"].([]interface{})
-		for _, bookmaker := range bookmakers {
-			markets := bookmaker.(map[string]interface{})["markets"].([]interface{})
-			for _, m := range markets {
-				marketMap := m.(map[string]interface{})
-				key := marketMap["key"].(string)
-				outcomes := marketMap["outcomes"].([]interface{})
-
-				for _, outcome := range outcomes {
-					outcomeMap := outcome.(map[string]interface{})
-					outcomeMap["time"] = time
-					outcomeMap["home_team"] = homeTeam
-					outcomeMap["away_team"] = awayTeam
-					oddsMap[key] = append(oddsMap[key], outcomeMap)
-				}
+	// Predictions stored under a team only price that team's games
+	if team, ok := def.TeamFromPath(statspath); ok {
+		teamEvents := make(map[EventKey]EventOdds)
+		for k, event := range oddsData {
+			if def.SameTeam(event.HomeTeam, team.Name) || def.SameTeam(event.AwayTeam, team.Name) {
+				teamEvents[k] = event
 			}
 		}
+		fmt.Printf("Pricing %d of %d events involving the %s\n", len(teamEvents), len(oddsData), team.Name)
+		oddsData = teamEvents
 	}
 
-	fmt.Println(oddsMap)
-
-	playerPoints := oddsMap["player_points"]
-	playerRebounds := oddsMap["player_rebounds"]
-	playerAssists := oddsMap["player_assists"]
-	playerBlocks := oddsMap["player_blocks"]
-	playerSteals := oddsMap["player_steals"]
-	playerTurnUnders := oddsMap["player_turnUnders"]
-	playerPointsRebounds := oddsMap["player_points_rebounds"]
-	playerPointsAssists := oddsMap["player_points_assists"]
-	playerReboundsAssists := oddsMap["player_rebounds_assists"]
-	playerPointsReboundsAssists := oddsMap["player_points_rebounds_assists"]
-
-	// Read stats from directory
-	stats := ReadPreds(statspath)
-
-	for player, playerStats := range stats {
-		points := playerStats["points"]
-		rebounds := playerStats["totReb"]
-		assists := playerStats["assists"]
-		blocks := playerStats["blocks"]
-		steals := playerStats["steals"]
-		turnUnders := playerStats["turnovers"]
-
-		playerName := strings.ReplaceAll(player, "_", " ")
-
-		pointsOdds := SearchPlayerOdds(playerPoints, playerName)
-		reboundsOdds := SearchPlayerOdds(playerRebounds, playerName)
-		assistsOdds := SearchPlayerOdds(playerAssists, playerName)
-		blocksOdds := SearchPlayerOdds(playerBlocks, playerName)
-		stealsOdds := SearchPlayerOdds(playerSteals, playerName)
-		turnUndersOdds := SearchPlayerOdds(playerTurnUnders, playerName)
-		pointsReboundsOdds := SearchPlayerOdds(playerPointsRebounds, playerName)
-		pointsAssistsOdds := SearchPlayerOdds(playerPointsAssists, playerName)
-		reboundsAssistsOdds := SearchPlayerOdds(playerReboundsAssists, playerName)
-		pointsReboundsAssistsOdds := SearchPlayerOdds(playerPointsReboundsAssists, playerName)
-
-		// Points odds
-		for _, pointBet := range pointsOdds {
-			value := float64(math.Ceil(pointBet["point"].(float64)))
-			cdf := CDF(points, value)
-			if pointBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
+	var inputs []ManifestInput
+	for _, k := range sortedEventKeys(oddsData) {
+		inputs = append(inputs, InputOf(oddsRefs[k], oddsData[k]))
+	}
 
-			odds := 1.0 / pointBet["price"].(float64)
-			differential := cdf - odds
-			pointBet["type"] = "points"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": pointBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           pointBet,
-			})
+	// extract odds and store in oddsMap
+	for _, event := range oddsData {
+		for key, outcomes := range FlattenOutcomes(event) {
+			oddsMap[key] = append(oddsMap[key], outcomes...)
 		}
+	}
 
-		// Rebounds odds
-		for _, reboundBet := range reboundsOdds {
-			value := float64(math.Ceil(reboundBet["point"].(float64)))
-			cdf := CDF(rebounds, value)
+	stats, err := store.LoadPredictions(key)
+	if err != nil {
+		fmt.Println(err)
+		return results
+	}
 
-			if reboundBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
+	for _, player := range SortedKeys(stats) {
+		inputs = append(inputs, InputOf(key.PredsRef(player), stats[player]))
+	}
 
-			odds := 1.0 / reboundBet["price"].(float64)
-			differential := cdf - odds
-			reboundBet["type"] = "rebounds"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": reboundBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           reboundBet,
-			})
+	aliases, err := LoadAliases(AliasPath(sport))
+	if err != nil {
+		fmt.Println(err)
+		return results
+	}
+	if raw, err := os.ReadFile(AliasPath(sport)); err == nil {
+		inputs = append(inputs, InputOfRaw(RefFromPath(AliasPath(sport)), string(raw)))
+	}
+	bookNames := make(map[string]bool)
+	for _, market := range def.Markets {
+		for _, outcome := range oddsMap[market.Key] {
+			bookNames[outcome.Description] = true
 		}
-
-		// Assist odds
-		for _, assistBet := range assistsOdds {
-			value := float64(math.Ceil(assistBet["point"].(float64)))
-			cdf := CDF(assists, value)
-
-			if assistBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
-
-			odds := 1.0 / assistBet["price"].(float64)
-			differential := cdf - odds
-			assistBet["type"] = "assists"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": assistBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           assistBet,
-			})
+	}
+	resolver := NewPlayerResolver(SortedKeys(bookNames), aliases, PlayerMatchThreshold)
+
+	report := MatchReport{Fuzzy: []PlayerMatch{}, UnmatchedPredictions: []PlayerMatch{}, UnmatchedOdds: []string{}}
+	matched := make(map[string]bool)
+	for _, player := range SortedKeys(stats) {
+		match := resolver.Resolve(player)
+		switch match.Method {
+		case "none":
+			report.UnmatchedPredictions = append(report.UnmatchedPredictions, match)
+			continue
+		case "fuzzy":
+			report.Fuzzy = append(report.Fuzzy, match)
 		}
+		matched[match.BookName] = true
 
-		// Blocks odds
-		for _, blockBet := range blocksOdds {
-			value := float64(math.Ceil(blockBet["point"].(float64)))
-			cdf := CDF(blocks, value)
-
-			if blockBet["name"] == "Under" {
-				cdf = 1 - cdf
+		for _, market := range def.Markets {
+			samples, ok := market.Samples(stats[player])
+			if !ok {
+				continue
 			}
-
-			odds := 1.0 / blockBet["price"].(float64)
-			differential := cdf - odds
-			blockBet["type"] = "blocks"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": blockBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           blockBet,
-			})
+			bets := SearchPlayerOdds(oddsMap[market.Key], match.BookName)
+			results = append(results, EvaluateBets(bets, samples, market)...)
 		}
-
-		// Steals odds
-		for _, stealBet := range stealsOdds {
-			value := float64(math.Ceil(stealBet["point"].(float64)))
-			cdf := CDF(steals, value)
-
-			if stealBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
-
-			odds := 1.0 / stealBet["price"].(float64)
-			differential := cdf - odds
-			stealBet["type"] = "steals"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": stealBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           stealBet,
-			})
+	}
+	for _, name := range SortedKeys(bookNames) {
+		if !matched[name] {
+			report.UnmatchedOdds = append(report.UnmatchedOdds, name)
 		}
+	}
 
-		// Turnovers odds
-		for _, turnUnderBet := range turnUndersOdds {
-			value := float64(math.Ceil(turnUnderBet["point"].(float64)))
-			cdf := CDF(turnUnders, value)
-
-			if turnUnderBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
-
-			odds := 1.0 / turnUnderBet["price"].(float64)
-			differential := cdf - odds
-			turnUnderBet["type"] = "turnovers"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": turnUnderBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           turnUnderBet,
-			})
-		}
+	fmt.Printf("Evaluated %d bets\n", len(results))
+	report.Print()
+	if encoded, err := json.Marshal(report); err != nil {
+		fmt.Printf("Error encoding player matches: %v\n", err)
+	} else if err := store.SaveDocument(key, "player_matches.json", string(encoded)); err != nil {
+		fmt.Printf("Error saving player matches: %v\n", err)
+	} else if err := store.SaveManifest(NewManifest(key.DocumentRef("player_matches.json"), inputs...)); err != nil {
+		fmt.Printf("Error saving player matches manifest: %v\n", err)
+	}
 
-		// Points + Rebounds odds
-		for _, pointReboundBet := range pointsReboundsOdds {
-			value := float64(math.Ceil(pointReboundBet["point"].(float64)))
-			combo := CombinationSum(points, rebounds)
-			cdf := CDF(combo, value)
+	err = store.SaveArbitrage(scope, results)
+	if err != nil {
+		fmt.Printf("Error saving file: %v\n", err)
+	} else {
+		fmt.Println("File saved successfully.")
+	}
+	if err := store.SaveManifest(NewManifest(scope.ArbitrageRef(), inputs...)); err != nil {
+		fmt.Printf("Error saving manifest: %v\n", err)
+	}
 
-			if pointReboundBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
+	return results
+}
 
-			odds := 1.0 / pointReboundBet["price"].(float64)
-			differential := cdf - odds
-			pointReboundBet["type"] = "points_rebounds"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": pointReboundBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           pointReboundBet,
-			})
+// EvaluateBets prices each over/under (or yes/no) outcome against predictive samples
+func EvaluateBets(bets []BetOutcome, samples []float64, market PlayerMarket) []ArbitrageResult {
+	results := make([]ArbitrageResult, 0, len(bets))
+	for _, bet := range bets {
+		if bet.Price <= 0 {
+			continue
 		}
-
-		// Points + Assists odds
-		for _, pointAssistBet := range pointsAssistsOdds {
-			value := float64(math.Ceil(pointAssistBet["point"].(float64)))
-			combo := CombinationSum(points, assists)
-			cdf := CDF(combo, value)
-
-			if pointAssistBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
-
-			odds := 1.0 / pointAssistBet["price"].(float64)
-			differential := cdf - odds
-			pointAssistBet["type"] = "points_assists"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": pointAssistBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           pointAssistBet,
-			})
+		point := market.Point
+		if bet.Point != nil {
+			point = *bet.Point
+		} else if market.Point == 0 {
+			continue
 		}
-
-		// Rebounds + Assists odds
-		for _, reboundAssistBet := range reboundsAssistsOdds {
-			value := float64(math.Ceil(reboundAssistBet["point"].(float64)))
-			combo := CombinationSum(rebounds, assists)
-			cdf := CDF(combo, value)
-
-			if reboundAssistBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
-
-			odds := 1.0 / reboundAssistBet["price"].(float64)
-			differential := cdf - odds
-			reboundAssistBet["type"] = "rebounds_assists"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": reboundAssistBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           reboundAssistBet,
-			})
-		}
-
-		// Points + Rebounds + Assists odds
-		for _, pointReboundAssistBet := range pointsReboundsAssistsOdds {
-			value := float64(math.Ceil(pointReboundAssistBet["point"].(float64)))
-			combo := CombinationSum(points, rebounds)
-			combo = CombinationSum(combo, assists)
-
-			cdf := CDF(combo, value)
-
-			if pointReboundAssistBet["name"] == "Under" {
-				cdf = 1 - cdf
-			}
-
-			odds := 1.0 / pointReboundAssistBet["price"].(float64)
-			differential := cdf - odds
-			pointReboundAssistBet["type"] = "points_rebounds_assists"
-
-			results = append(results, map[string]any{
-				"ExpectedValue": pointReboundAssistBet["price"].(float64),
-				"BookProb":      odds,
-				"ModelProb":     cdf,
-				"Differential":  differential,
-				"Bet":           pointReboundAssistBet,
-			})
+		// Over and Yes pay when the total clears the line, P(X > point),
+		// for half-point and whole lines alike; Under and No take the rest
+		cdf := 1 - CDF(samples, point)
+		switch bet.Name {
+		case "Under", "No":
+			cdf = 1 - cdf
 		}
-	}
 
-	fmt.Println(results)
+		odds := 1.0 / bet.Price
+		bet.Type = market.Type
 
-	err := SaveResultsToFile(results, oddspath, "arbitrage.json")
-	if err != nil {
-			fmt.Printf("Error saving file: %v\n", err)
-	} else {
-			fmt.Println("File saved successfully.")
+		results = append(results, ArbitrageResult{
+			ExpectedValue: bet.Price,
+			BookProb:      odds,
+			ModelProb:     cdf,
+			Differential:  cdf - odds,
+			Bet:           bet,
+		})
 	}
-	
 	return results
 }
 
-func SearchPlayerOdds(m []map[string]any, val string) []map[string]any {
-	odds := make([]map[string]any, 0)
+func SearchPlayerOdds(m []BetOutcome, val string) []BetOutcome {
+	odds := make([]BetOutcome, 0)
 	for _, v := range m {
-		if v["description"] == val {
-			odds = append(odds, v) 
+		if v.Description == val {
+			odds = append(odds, v)
 		}
 	}
 	return odds
@@ -468,54 +332,65 @@ func RenameDirsInDir(dir string) error {
 	return nil
 }
 
-func ReadOdds(dir string) map[string]interface{} {
-	// Rename directories
-	err := RenameDirsInDir(dir)
-	if err != nil {
-		panic(fmt.Errorf("failed to rename directories: %w", err))
+// OddsAtSpec returns the primary odds of every event in scope, but for each
+// event substitutes the latest snapshot taken at or before the time at names.
+// Events without such a snapshot keep the primary one. refs names the
+// output each event's odds came from.
+func OddsAtSpec(store Store, scope OddsScope, at string) (map[EventKey]EventOdds, map[EventKey]string, error) {
+	var spec *SnapshotSpec
+	if at != "" {
+		parsed, err := ParseSnapshotSpec(at)
+		if err != nil {
+			return nil, nil, err
+		}
+		spec = &parsed
 	}
 
-	// Prepare map to hold data
-	data := make(map[string]interface{})
+	data, err := store.Odds(scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	refs := make(map[EventKey]string, len(data))
+	for key := range data {
+		refs[key] = key.OddsRef()
+	}
+	if spec == nil {
+		return data, refs, nil
+	}
 
-	// Walk through the directory and process `odds.json` files
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	for key, event := range data {
+		commence, err := ParseTime(event.CommenceTime)
 		if err != nil {
-			return fmt.Errorf("error accessing path %s: %w", path, err)
+			fmt.Printf("Keeping %s %s: %v\n", key.Date, key.Event, err)
+			continue
 		}
-
-		// Ensure it's a file and has the correct extension
-		if !info.IsDir() && filepath.Ext(info.Name()) == ".json" && info.Name() == "odds.json" {
-			fmt.Println("Reading JSON file:", path)
-
-			// Read file content
-			fileData, err := os.ReadFile(path)
-			if err != nil {
-				return fmt.Errorf("failed to read file %s: %w", path, err)
-			}
-
-			// Unmarshal JSON content
-			var fileContent interface{}
-			err = json.Unmarshal(fileData, &fileContent)
-			if err != nil {
-				return fmt.Errorf("failed to unmarshal JSON from file %s: %w", path, err)
-			}
-
-			// Store data with file path as key
-			data[path] = fileContent
+		snapshot, ok, err := store.OddsAt(key, spec.Time(commence))
+		if err != nil {
+			return nil, nil, err
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		panic(fmt.Errorf("failed to process directory: %w", err))
+		if !ok {
+			fmt.Printf("No %s snapshot for %s %s, keeping the primary odds\n", spec.Label, key.Date, key.Event)
+			continue
+		}
+		data[key] = snapshot.Event
+		refs[key] = key.SnapshotRef(snapshot.Label, snapshot.Timestamp)
 	}
+	return data, refs, nil
+}
 
-	// Print the collected data for debugging
-	fmt.Println("Collected Data:", data)
-
-	return data
+// sortedEventKeys returns the keys of an odds map by date and event
+func sortedEventKeys(data map[EventKey]EventOdds) []EventKey {
+	keys := make([]EventKey, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].Date != keys[b].Date {
+			return keys[a].Date < keys[b].Date
+		}
+		return keys[a].Event < keys[b].Event
+	})
+	return keys
 }
 
 func ReadPreds(dir string) map[string]map[string][]float64 {
@@ -527,6 +402,9 @@ func ReadPreds(dir string) map[string]map[string][]float64 {
 
 	for _, file := range files {
 		name := file.Name()
+		if IsManifest(name) {
+			continue
+		}
 		player := strings.TrimSuffix(name, "_preds.json")
 		path := filepath.Join(dir, name)
 
@@ -541,7 +419,6 @@ func ReadPreds(dir string) map[string]map[string][]float64 {
 		jsonData := make(map[string]interface{})
 		err = json.NewDecoder(f).Decode(&jsonData)
 
-		
 		if len(jsonData) > 0 && err == nil {
 
 			var key string
@@ -549,31 +426,31 @@ func ReadPreds(dir string) map[string]map[string][]float64 {
 				key = k
 				break
 			}
-			playerPredsIface, ok := jsonData[key].([]interface{})
-			if !ok {
+			switch preds := jsonData[key].(type) {
+			case map[string]interface{}:
+				// Predictions keyed by metric
+				metrics := make(map[string][]float64)
+				for metric, samples := range preds {
+					values, ok := samples.([]interface{})
+					if !ok {
+						panic(fmt.Errorf("unexpected structure for %s predictions of player %s", metric, player))
+					}
+					metrics[metric] = toFloat64Slice(values)
+				}
+				data[player] = metrics
+			case []interface{}:
+				// Older files list NBA metrics in the order bayes fitted them
+				if len(preds) < 6 {
+					panic(fmt.Errorf("not enough prediction arrays for player %s", player))
+				}
+				metrics := make(map[string][]float64)
+				for i, metric := range SportMetrics("nba") {
+					metrics[metric] = toFloat64Slice(preds[i].([]interface{}))
+				}
+				data[player] = metrics
+			default:
 				panic(fmt.Errorf("unexpected structure for player predictions"))
 			}
-
-
-			if len(playerPredsIface) < 6 {
-				panic(fmt.Errorf("not enough prediction arrays for player %s", player))
-			}
-
-			points := toFloat64Slice(playerPredsIface[0].([]interface{}))
-			assists := toFloat64Slice(playerPredsIface[1].([]interface{}))
-			totReb := toFloat64Slice(playerPredsIface[2].([]interface{}))
-			blocks := toFloat64Slice(playerPredsIface[3].([]interface{}))
-			steals := toFloat64Slice(playerPredsIface[4].([]interface{}))
-			turnovers := toFloat64Slice(playerPredsIface[5].([]interface{}))
-
-			data[player] = map[string][]float64{
-				"points":    points,
-				"assists":   assists,
-				"totReb":    totReb,
-				"blocks":    blocks,
-				"steals":    steals,
-				"turnovers": turnovers,
-			}
 		}
 	}
 	return data
@@ -591,28 +468,22 @@ func toFloat64Slice(arr []interface{}) []float64 {
 	return out
 }
 
-
-
 // sanitizeResults replaces any NaN or Inf values with 0.0
-func sanitizeResults(results []map[string]any) []map[string]any {
-	for _, r := range results {
-			for k, v := range r {
-					if f, ok := v.(float64); ok {
-							if math.IsNaN(f) || math.IsInf(f, 0) {
-									r[k] = 0.0
-							}
-					}
-					// If the value is nested map/array that might contain floats,
-					// you'd need to recursively sanitize those as well.
+func sanitizeResults(results []ArbitrageResult) []ArbitrageResult {
+	for i := range results {
+		for _, f := range []*float64{&results[i].ExpectedValue, &results[i].BookProb, &results[i].ModelProb, &results[i].Differential} {
+			if math.IsNaN(*f) || math.IsInf(*f, 0) {
+				*f = 0.0
 			}
+		}
 	}
 	return results
 }
 
-func SaveResultsToFile(results []map[string]any, dir string, filename string) error {
+func SaveResultsToFile(results []ArbitrageResult, dir string, filename string) error {
 	// Ensure directory exists
 	if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directories for %s: %w", dir, err)
+		return fmt.Errorf("failed to create directories for %s: %w", dir, err)
 	}
 
 	// Sanitize results to remove NaNs
@@ -621,13 +492,13 @@ func SaveResultsToFile(results []map[string]any, dir string, filename string) er
 	outputPath := filepath.Join(dir, filename)
 	jsonData, err := json.MarshalIndent(results, "", "  ")
 	if err != nil {
-			return fmt.Errorf("failed to marshal results: %w", err)
+		return fmt.Errorf("failed to marshal results: %w", err)
 	}
 
 	if err := os.WriteFile(outputPath, jsonData, 0644); err != nil {
-			return fmt.Errorf("failed to write results to file %s: %w", outputPath, err)
+		return fmt.Errorf("failed to write results to file %s: %w", outputPath, err)
 	}
 
 	fmt.Printf("Results successfully written to %s\n", outputPath)
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,50 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluateBetsPricesLines(t *testing.T) {
+	samples := []float64{0, 1, 1, 2, 2, 2, 3, 3, 4, 5}
+	line := func(x float64) *float64 { return &x }
+
+	cases := []struct {
+		name   string
+		point  *float64
+		market float64 // the market's own line, for yes/no bets without one
+		want   float64
+	}{
+		{"Over", line(2.5), 0, 0.4},
+		{"Under", line(2.5), 0, 0.6},
+		// A whole line pushes on 2: Over wins only above it
+		{"Over", line(2), 0, 0.4},
+		{"Under", line(2), 0, 0.6},
+		{"Yes", nil, 0.5, 0.9},
+		{"No", nil, 0.5, 0.1},
+		{"Yes", line(1), 0.5, 0.7},
+		{"No", line(1), 0.5, 0.3},
+	}
+	for i, c := range cases {
+		bet := BetOutcome{Outcome: Outcome{Name: c.name, Price: 2.5, Point: c.point}}
+		results := EvaluateBets([]BetOutcome{bet}, samples, PlayerMarket{Type: "points", Point: c.market})
+		if len(results) != 1 {
+			t.Fatalf("%s: got %d results, want 1", c.name, len(results))
+		}
+		r := results[0]
+		if math.Abs(r.ModelProb-c.want) > 1e-12 {
+			t.Errorf("case %d %s: model probability %.2f, want %.2f", i, c.name, r.ModelProb, c.want)
+		}
+		if r.BookProb != 0.4 || math.Abs(r.Differential-(c.want-0.4)) > 1e-12 {
+			t.Errorf("%s: book probability %.2f and differential %.2f, want 0.40 and %.2f", c.name, r.BookProb, r.Differential, c.want-0.4)
+		}
+		if r.Bet.Type != "points" {
+			t.Errorf("%s: bet type %q, want the market's", c.name, r.Bet.Type)
+		}
+	}
+
+	// Bets without a line on a market without one cannot be priced
+	if results := EvaluateBets([]BetOutcome{{Outcome: Outcome{Name: "Yes", Price: 2}}}, samples, PlayerMarket{}); len(results) != 0 {
+		t.Errorf("priced a bet without a line: %+v", results)
+	}
+}
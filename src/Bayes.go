@@ -1,12 +1,13 @@
 package src
+
 import (
 	"math"
-	"math/rand"
 	"slices"
+	"sync"
 
+	"golang.org/x/exp/rand"
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/stat/distuv"
-	"fmt"
 )
 
 // Distribution interface
@@ -26,9 +27,9 @@ type DiscreteDistribution interface {
 }
 
 type DistributionParams struct {
-	Dist   string
+	Dist     string
 	DistType string
-	Params map[string]float64
+	Params   map[string]float64
 }
 
 type Prior struct {
@@ -38,9 +39,10 @@ type Prior struct {
 type Likelihood struct {
 	Params             []float64
 	DistributionParams DistributionParams
-	InputData               mat.Dense
-	OutputData              mat.VecDense
-	Link               func([]float64 , []float64) []float64
+	InputData          mat.Dense
+	OutputData         mat.VecDense
+	Link               func([]float64, []float64) []float64
+	LinkJacobian       LinkJacobian // derivative of Link, for exact gradients
 }
 
 type Posterior struct {
@@ -48,19 +50,52 @@ type Posterior struct {
 	Data             mat.Dense
 	LikelihoodParams DistributionParams
 	MarkovChain      MarkovChain
+	Seed             uint64 // each chain and the predictive draw from streams derived from it
 }
 
 type PosteriorResult struct {
-	Params      []float64
+	Params        []float64
 	LogLikelihood float64
 }
 
+// ChainResult is the draws of one chain and the seed it was started from
+type ChainResult struct {
+	Chain   int
+	Seed    uint64
+	Samples []PosteriorResult
+	Stats   SamplerStats
+}
+
+// PoolChains concatenates the draws of every chain
+func PoolChains(chains []ChainResult) []PosteriorResult {
+	var pooled []PosteriorResult
+	for _, c := range chains {
+		pooled = append(pooled, c.Samples...)
+	}
+	return pooled
+}
+
 type MarkovChain struct {
 	Distributions []DistributionParams
 	Grid          mat.Dense
 	Likelihood    Likelihood
 	SampleSize    int
 	Sampler       string
+	Steps         int          // draws kept per chain, 0 for the sampler's default
+	Warmup        int          // draws discarded before them, 0 for the sampler's default
+	Rand          *rand.Rand   // the chain's generator, seeded from Seed when nil
+	Stats         SamplerStats // set by gradient-based and adaptive samplers
+}
+
+// Copy returns a likelihood with its own parameters, sharing the data
+func (l Likelihood) Copy() Likelihood {
+	l.Params = append([]float64{}, l.Params...)
+	params := make(map[string]float64, len(l.DistributionParams.Params))
+	for k, v := range l.DistributionParams.Params {
+		params[k] = v
+	}
+	l.DistributionParams.Params = params
+	return l
 }
 
 func (l *Likelihood) CalcDataLikelihood() float64 {
@@ -75,38 +110,57 @@ func (l *Likelihood) CalcDataLikelihood() float64 {
 		}
 		//fmt.Println(data)
 		//fmt.Println(l.Params)
+		// The link sets the leading parameters; any after them, such as
+		// a Binomial's trials, keep their fixed values
 		selectedParams := l.Link(l.Params, inputdata)
 		paramKeys := getParamKeys(distType)
-		for i, key := range paramKeys {
-			l.DistributionParams.Params[key] = selectedParams[i]
+		for i, val := range selectedParams {
+			l.DistributionParams.Params[paramKeys[i]] = val
 		}
 
 		outputdata := make([]float64, l.OutputData.Len())
 
 		for j := 0; j < l.OutputData.Len(); j++ {
-			outputdata[j] = l.OutputData.At(j,0)
+			outputdata[j] = l.OutputData.At(j, 0)
 		}
 
 		//fmt.Println(l.DistributionParams.Params)
 
 		switch distType {
-			case "Normal":
-				mu := l.DistributionParams.Params["Mu"]
-				sigma := l.DistributionParams.Params["Sigma"]
-				NegLogLikelihood += -UVNormalLogLikelihood(mu, sigma, outputdata)
-			case "Poisson":
-				lambda := l.DistributionParams.Params["Lambda"]
-				NegLogLikelihood += -UVPoissonLogLikelihood(lambda, outputdata)
-			case "Exponential":
-				rate := l.DistributionParams.Params["Rate"]
-				NegLogLikelihood += -UVExponentialLogLikelihood(rate, outputdata)
-			case "Uniform":
-				min := l.DistributionParams.Params["Min"]
-				max := l.DistributionParams.Params["Max"]
-				n := float64(len(outputdata))
-				NegLogLikelihood += -UVUniformLogLikelihood(min, max, n)
+		case "Normal":
+			mu := l.DistributionParams.Params["Mu"]
+			sigma := l.DistributionParams.Params["Sigma"]
+			NegLogLikelihood += -UVNormalLogLikelihood(mu, sigma, outputdata)
+		case "Poisson":
+			lambda := l.DistributionParams.Params["Lambda"]
+			NegLogLikelihood += -UVPoissonLogLikelihood(lambda, outputdata)
+		case "Exponential":
+			rate := l.DistributionParams.Params["Rate"]
+			NegLogLikelihood += -UVExponentialLogLikelihood(rate, outputdata)
+		case "NegativeBinomial":
+			mu := l.DistributionParams.Params["Mu"]
+			alpha := l.DistributionParams.Params["Alpha"]
+			NegLogLikelihood += -UVNegativeBinomialLogLikelihood(mu, alpha, outputdata)
+		case "ZeroInflatedPoisson":
+			lambda := l.DistributionParams.Params["Lambda"]
+			pi := l.DistributionParams.Params["Pi"]
+			NegLogLikelihood += -UVZeroInflatedPoissonLogLikelihood(lambda, pi, outputdata)
+		case "ZeroInflatedNegativeBinomial":
+			mu := l.DistributionParams.Params["Mu"]
+			alpha := l.DistributionParams.Params["Alpha"]
+			pi := l.DistributionParams.Params["Pi"]
+			NegLogLikelihood += -UVZeroInflatedNegativeBinomialLogLikelihood(mu, alpha, pi, outputdata)
+		case "Binomial":
+			n := l.DistributionParams.Params["N"]
+			p := l.DistributionParams.Params["P"]
+			NegLogLikelihood += -UVBinomialLogLikelihood(n, p, outputdata)
+		case "Uniform":
+			min := l.DistributionParams.Params["Min"]
+			max := l.DistributionParams.Params["Max"]
+			n := float64(len(outputdata))
+			NegLogLikelihood += -UVUniformLogLikelihood(min, max, n)
 		}
-}
+	}
 
 	return NegLogLikelihood
 }
@@ -114,7 +168,7 @@ func (l *Likelihood) CalcDataLikelihood() float64 {
 func UVNormalLogLikelihood(mu float64, sigma float64, data []float64) float64 {
 	sum := -float64(len(data)) / 2.0 * math.Log(2*math.Pi*math.Pow(sigma, 2))
 	for _, d := range data {
-		sum -= (1.0 / 2.0 * math.Pow(sigma, 2)) * math.Pow((d-mu), 2)
+		sum -= math.Pow((d-mu), 2) / (2.0 * math.Pow(sigma, 2))
 	}
 	return sum
 }
@@ -135,35 +189,102 @@ func UVExponentialLogLikelihood(rate float64, data []float64) float64 {
 	return sum
 }
 
+// UVNegativeBinomialLogLikelihood is the log likelihood of counts with mean
+// mu and dispersion alpha, whose variance is mu + mu^2/alpha
+func UVNegativeBinomialLogLikelihood(mu float64, alpha float64, data []float64) float64 {
+	sum := 0.0
+	for _, d := range data {
+		lgAlphaD, _ := math.Lgamma(d + alpha)
+		lgAlpha, _ := math.Lgamma(alpha)
+		lgD, _ := math.Lgamma(d + 1)
+		sum += lgAlphaD - lgAlpha - lgD + alpha*math.Log(alpha/(alpha+mu)) + d*math.Log(mu/(alpha+mu))
+	}
+	return sum
+}
+
 func UVUniformLogLikelihood(min float64, max float64, n float64) float64 {
 	return -n * math.Log(max-min)
 }
 
-func (p *Posterior) CalcPosterior(chains int) []PosteriorResult {
-	// Create the grid
-	p.MarkovChain.CreateGrid()
+// CalcPosterior runs chains independent chains concurrently over one grid
+// and returns the draws of each
+func (p *Posterior) CalcPosterior(chains int) []ChainResult {
+	if chains < 1 {
+		chains = 1
+	}
 
-	// generate initial state for the Markov Chain (random row in grid)
-	numCombos := p.MarkovChain.Grid.RawMatrix().Rows
+	// Create the grid, shared by every chain
+	if p.MarkovChain.UsesGrid() {
+		p.MarkovChain.Rand = NewRand(DeriveSeed(p.Seed, "grid"))
+		p.MarkovChain.CreateGrid()
+	}
 
-	index := int64(math.Ceil(rand.Float64() * float64(numCombos)))
+	results := make([]ChainResult, chains)
+	var wg sync.WaitGroup
+	for c := 0; c < chains; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			// CalcDataLikelihood writes to the likelihood's parameter map,
+			// so every chain samples with its own copy
+			mc := p.MarkovChain
+			mc.Likelihood = p.MarkovChain.Likelihood.Copy()
+			seed := ChainSeed(p.Seed, c)
+			mc.Rand = NewRand(seed)
+			samples := p.runChain(&mc)
+			results[c] = ChainResult{Chain: c, Seed: seed, Samples: samples, Stats: mc.Stats}
+		}(c)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// draws returns the draws a chain keeps and the warmup before them: Steps
+// and Warmup when set, else 5000 after 2500 of warmup, or 1000 after 1000
+// for NUTS, whose draws are nearly independent
+func (m *MarkovChain) draws() (int, int) {
+	steps, warmup := 5000, 2500
+	if m.Sampler == "NUTS" {
+		steps, warmup = 1000, 1000
+	}
+	if m.Steps > 0 {
+		steps = m.Steps
+	}
+	if m.Warmup > 0 {
+		warmup = m.Warmup
+	}
+	return steps, warmup
+}
+
+// runChain samples one chain from a random row of the grid
+func (p *Posterior) runChain(mc *MarkovChain) []PosteriorResult {
+	// generate initial state for the Markov Chain (random row in grid)
+	var index int64
+	if mc.UsesGrid() {
+		index = int64(mc.rng().Intn(mc.Grid.RawMatrix().Rows))
+	}
 
-	numsteps := 5000
+	numsteps, warmup := mc.draws()
 
 	likelihoods := make([]float64, numsteps+1)
 	samples := make([][]float64, numsteps+1)
 
-	switch p.MarkovChain.Sampler {
+	switch mc.Sampler {
 	case "Unit":
-		samples, likelihoods = p.MarkovChain.UnitRandomWalk(int64(index), numsteps)
+		samples, likelihoods = mc.UnitRandomWalk(int64(index), numsteps)
 	case "Lattice":
-		samples, likelihoods = p.MarkovChain.LatticeRandomWalk(int64(index), numsteps)
+		samples, likelihoods = mc.LatticeRandomWalk(int64(index), numsteps)
 	case "Gaussian":
-		samples, likelihoods = p.MarkovChain.GaussianRandomWalk(int64(index), numsteps)
+		samples, likelihoods = mc.GaussianRandomWalk(int64(index), numsteps)
 	case "Metropolis":
-		samples, likelihoods = p.MarkovChain.MetropolisHastings(int64(index), int(float64(numsteps) * 1.5), int(float64(numsteps)/2.0) )
+		samples, likelihoods = mc.MetropolisHastings(int64(index), numsteps+warmup, warmup)
 	case "Hamiltonian":
-		samples, likelihoods = p.MarkovChain.HamiltonianMonteCarlo(int64(index), numsteps)
+		samples, likelihoods = mc.HamiltonianMonteCarlo(int64(index), numsteps)
+	case "Adaptive":
+		samples, likelihoods = mc.AdaptiveMetropolis(numsteps+warmup, warmup)
+	case "NUTS":
+		samples, likelihoods = mc.NoUTurn(numsteps, warmup)
 	}
 
 	// take index, get prior params. take CDF of prior params, multiply by likelihood
@@ -208,7 +329,7 @@ func (p *Posterior) CalcPosterior(chains int) []PosteriorResult {
 	results := make([]PosteriorResult, len(samples))
 	for i, sample := range samples {
 		results[i] = PosteriorResult{
-			Params:      sample,
+			Params:        sample,
 			LogLikelihood: likelihoods[i],
 		}
 	}
@@ -216,62 +337,41 @@ func (p *Posterior) CalcPosterior(chains int) []PosteriorResult {
 	return results
 }
 
-func (p *Posterior) CalcPosteriorPredictive(results []PosteriorResult, data [][]float64, numsamples int, linkfunc func([]float64, []float64) []float64) []float64 {
-	//weights correspond to likelihoods
-	// for num samples, weighted randomly select a result based on likelihood
-	// for each result, sample a data point from the likelihood distribution using the result's parameters as priors
-	// return the samples
-	weights := make([]float64, len(results))
-	posteriorIndices := make([]int64, len(results))
-
-	for i, result := range results {
-		posteriorIndices[i] = int64(i)
-		weights[i] = result.LogLikelihood   
+// CalcPosteriorPredictive draws numsamples values for the game with the
+// given lag features: each draw takes a parameter vector from the posterior
+// samples at random, maps it through linkfunc to the likelihood's parameters
+// for that game and samples the likelihood, so the draws carry parameter
+// uncertainty as well as the likelihood's own noise
+func (p *Posterior) CalcPosteriorPredictive(results []PosteriorResult, features []float64, numsamples int, linkfunc func([]float64, []float64) []float64) []float64 {
+	if len(results) == 0 {
+		return nil
 	}
-	
-
-	//get minumum weight
-	maxLL := weights[0]
-	maxLLIndex := 0
+	rng := NewRand(DeriveSeed(p.Seed, "predictive"))
+	keys := getParamKeys(p.LikelihoodParams.Dist)
 
-	for index, weight := range weights {
-		if weight > maxLL {
-			maxLL = weight
-			maxLLIndex = index
-		}
+	params := DistributionParams{
+		Dist:     p.LikelihoodParams.Dist,
+		DistType: p.LikelihoodParams.DistType,
+		Params:   make(map[string]float64, len(p.LikelihoodParams.Params)),
 	}
-
-	//these params are from Markov Chain Grid, must be transformed to rate variable
-	maxLLParams := results[maxLLIndex].Params
-	tempparams := make([]float64, len(maxLLParams))
-
-	//fmt.Println(data)
-
-	for _, d := range data {
-
-
-		linkedParams := linkfunc(maxLLParams, d)
-
-		for i, j := range linkedParams {
-			tempparams[i] += (j / float64(len(data)))
-		}
+	for k, v := range p.LikelihoodParams.Params {
+		params.Params[k] = v
 	}
-	maxLLParams = tempparams
-	
-
-	maxLLDistParams := p.LikelihoodParams.Params
-	
-	LLKeys := getParamKeys(p.LikelihoodParams.Dist)
 
-	for i, key := range LLKeys {
-		maxLLDistParams[key] = maxLLParams[i]
+	posteriorSamples := make([]float64, numsamples)
+	for i := range posteriorSamples {
+		draw := results[rng.Intn(len(results))]
+		for j, val := range linkfunc(draw.Params, features) {
+			params.Params[keys[j]] = val
+		}
+		dist := params.CreateDist(rng)
+		if dist == nil {
+			return nil
+		}
+		posteriorSamples[i] = dist.Rand()
 	}
 
-	MaxLLDist := p.LikelihoodParams.CreateDist() 
-
-	fmt.Println(maxLLDistParams)
-
-	posteriorSamples := SampleDist(MaxLLDist, numsamples)
+	slices.Sort(posteriorSamples)
 
 	return posteriorSamples
 }
@@ -295,7 +395,7 @@ func (m *MarkovChain) UnitRandomWalk(index int64, numsteps int) ([][]float64, []
 		// find neighbors of closest point
 		neighbors := m.GetNeighbors(index)
 		// randomly select neighbor
-		index = neighbors[rand.Intn(len(neighbors))]
+		index = neighbors[m.rng().Intn(len(neighbors))]
 
 		indices[i+1] = index
 
@@ -349,7 +449,7 @@ func (m *MarkovChain) LatticeRandomWalk(index int64, numsteps int) ([][]float64,
 		neighborLikelihoods = Normalize(neighborLikelihoods)
 		// randomly select neighbor based on normalized likelihood
 		slices.Sort(neighborLikelihoods)
-		rand := rand.Float64()
+		rand := m.rng().Float64()
 		neighborIndex := 0
 		neighborLL := 0.0
 		minDiff := 1.0
@@ -402,7 +502,7 @@ func (m *MarkovChain) GaussianRandomWalk(index int64, numsteps int) ([][]float64
 		},
 	}
 
-	dist := distparams.CreateDist()
+	dist := distparams.CreateDist(m.rng())
 
 	for i := 0; i < numsteps; i++ {
 		var samples []int64
@@ -437,7 +537,7 @@ func (m *MarkovChain) GaussianRandomWalk(index int64, numsteps int) ([][]float64
 
 		// randomly select neighbor based on normalized likelihood
 		slices.Sort(neighborLikelihoods)
-		rand := rand.Float64()
+		rand := m.rng().Float64()
 		neighborIndex := 0
 		neighborLL := 0.0
 		minDiff := 1.0
@@ -489,7 +589,7 @@ func (m *MarkovChain) MetropolisHastings(index int64, numsteps int, burnin int)
 		numNeighbors := len(neighbors)
 
 		// Randomly select a neighbor
-		proposedIndex := neighbors[rand.Intn(numNeighbors)]
+		proposedIndex := neighbors[m.rng().Intn(numNeighbors)]
 
 		// Handle index wrapping
 		if proposedIndex < 0 {
@@ -509,7 +609,7 @@ func (m *MarkovChain) MetropolisHastings(index int64, numsteps int, burnin int)
 			acceptanceProb = 1
 		}
 
-		accepted := rand.Float64() < acceptanceProb
+		accepted := m.rng().Float64() < acceptanceProb
 
 		if accepted {
 			// Accept the proposed state
@@ -530,7 +630,6 @@ func (m *MarkovChain) MetropolisHastings(index int64, numsteps int, burnin int)
 	return samples[burnin:], likelihoods[burnin:]
 }
 
-
 func (m *MarkovChain) HamiltonianMonteCarlo(index int64, numSamples int) ([][]float64, []float64) {
 	indices := make([]int64, numSamples+1)
 	likelihoods := make([]float64, numSamples+1)
@@ -548,12 +647,18 @@ func (m *MarkovChain) HamiltonianMonteCarlo(index int64, numSamples int) ([][]fl
 
 	// Gradient of the negative log probability
 	dVdq := func(q []float64) []float64 {
+		if m.Likelihood.HasGradient() {
+			m.Likelihood.Params = q
+			_, grad := m.Likelihood.Gradient()
+			return grad
+		}
 		return gradient(negativeLogProb, q)
 	}
 
 	normalDist := distuv.Normal{
 		Mu:    0,
 		Sigma: 1,
+		Src:   m.rng(),
 	}
 
 	// Start sampling
@@ -592,7 +697,7 @@ func (m *MarkovChain) HamiltonianMonteCarlo(index int64, numSamples int) ([][]fl
 		if acceptanceProb > 1 {
 			acceptanceProb = 1
 		}
-		accepted := rand.Float64() < acceptanceProb
+		accepted := m.rng().Float64() < acceptanceProb
 
 		if accepted {
 			// Accept the new sample
@@ -614,7 +719,7 @@ func (m *MarkovChain) CreateGrid() {
 	DistSamples := make([][]float64, len(m.Distributions))
 
 	for i, distParams := range m.Distributions {
-		dist := distParams.CreateDist()
+		dist := distParams.CreateDist(m.rng())
 		DistSamples[i] = make([]float64, m.SampleSize)
 
 		// Generate samples uniformly from the distribution
@@ -634,7 +739,6 @@ func (m *MarkovChain) CreateGrid() {
 		DistComb = Combination(DistComb, dist)
 	}
 
-
 	// Fill the grid with the combined points
 	for i, row := range DistComb {
 		for j, val := range row {
@@ -642,78 +746,125 @@ func (m *MarkovChain) CreateGrid() {
 		}
 	}
 
-
 	m.Grid = *grid
 }
 
-func (d *DistributionParams) CreateDist() Distribution {
+// CreateDist builds the distribution, drawing from src (the global source
+// when nil)
+func (d *DistributionParams) CreateDist(src rand.Source) Distribution {
 	switch d.Dist {
 	case "Bernoulli":
 		return distuv.Bernoulli{
-			P: d.Params["P"],
+			P:   d.Params["P"],
+			Src: src,
 		}
 	case "Beta":
 		return distuv.Beta{
 			Alpha: d.Params["Alpha"],
 			Beta:  d.Params["Beta"],
+			Src:   src,
 		}
 	case "Binomial":
 		return distuv.Binomial{
-			N: d.Params["N"],
-			P: d.Params["P"],
+			N:   d.Params["N"],
+			P:   d.Params["P"],
+			Src: src,
 		}
 	case "ChiSquared":
 		return distuv.ChiSquared{
-			K: d.Params["K"],
+			K:   d.Params["K"],
+			Src: src,
 		}
 	case "Exponential":
 		return distuv.Exponential{
 			Rate: d.Params["Rate"],
+			Src:  src,
 		}
 	case "Gamma":
 		return distuv.Gamma{
 			Alpha: d.Params["Alpha"],
 			Beta:  d.Params["Beta"],
+			Src:   src,
 		}
 	case "LogNormal":
 		return distuv.LogNormal{
 			Mu:    d.Params["Mu"],
 			Sigma: d.Params["Sigma"],
+			Src:   src,
 		}
 	case "Normal":
 		return distuv.Normal{
 			Mu:    d.Params["Mu"],
 			Sigma: d.Params["Sigma"],
+			Src:   src,
+		}
+	case "NegativeBinomial":
+		return NegativeBinomial{
+			Mu:    d.Params["Mu"],
+			Alpha: d.Params["Alpha"],
+			Src:   src,
+		}
+	case "ZeroInflatedPoisson":
+		return ZeroInflated{
+			Pi:    d.Params["Pi"],
+			Count: distuv.Poisson{Lambda: d.Params["Lambda"], Src: src},
+			Src:   src,
+		}
+	case "ZeroInflatedNegativeBinomial":
+		return ZeroInflated{
+			Pi:    d.Params["Pi"],
+			Count: NegativeBinomial{Mu: d.Params["Mu"], Alpha: d.Params["Alpha"], Src: src},
+			Src:   src,
 		}
 	case "Pareto":
 		return distuv.Pareto{
 			Xm:    d.Params["Xm"],
 			Alpha: d.Params["Alpha"],
+			Src:   src,
 		}
 	case "Poisson":
 		return distuv.Poisson{
 			Lambda: d.Params["Lambda"],
+			Src:    src,
 		}
 	case "StudentsT":
 		return distuv.StudentsT{
 			Mu:    d.Params["Mu"],
 			Sigma: d.Params["Sigma"],
 			Nu:    d.Params["Nu"],
+			Src:   src,
 		}
 	case "Uniform":
 		return distuv.Uniform{
 			Min: d.Params["Min"],
 			Max: d.Params["Max"],
+			Src: src,
 		}
 	case "Weibull":
 		return distuv.Weibull{
 			K:      d.Params["K"],
 			Lambda: d.Params["Lambda"],
+			Src:    src,
 		}
 	default:
 		return nil
 	}
 }
+
+// UsesGrid reports whether the sampler walks the grid built by CreateGrid
+// rather than continuous parameter space
+func (m *MarkovChain) UsesGrid() bool {
+	return m.Sampler != "Adaptive" && m.Sampler != "NUTS"
+}
+
+// rng is the chain's generator
+func (m *MarkovChain) rng() *rand.Rand {
+	if m.Rand == nil {
+		m.Rand = NewRand(Seed)
+	}
+	return m.Rand
+}
+
 func (m *MarkovChain) GetNeighbors(index int64) []int64 {
 	neighbors := []int64{}
 
@@ -833,6 +984,9 @@ func Map[S ~[]E, E any](s S, f mapFunc[E]) S {
 	}
 	return result
 }
+
+// SampleDist draws sorted samples from dist, using the source it was created
+// with
 func SampleDist(dist Distribution, num_samples int) []float64 {
 	if dist == nil {
 		return nil
@@ -846,6 +1000,7 @@ func SampleDist(dist Distribution, num_samples int) []float64 {
 
 	return samples
 }
+
 func getParamKeys(distType string) []string {
 	switch distType {
 	case "Normal":
@@ -855,7 +1010,8 @@ func getParamKeys(distType string) []string {
 	case "Beta":
 		return []string{"Alpha", "Beta"}
 	case "Binomial":
-		return []string{"N", "P"}
+		// N, the number of trials, is fixed rather than set by a link
+		return []string{"P", "N"}
 	case "ChiSquared":
 		return []string{"K"}
 	case "Exponential":
@@ -868,6 +1024,12 @@ func getParamKeys(distType string) []string {
 		return []string{"Xm", "Alpha"}
 	case "Poisson":
 		return []string{"Lambda"}
+	case "NegativeBinomial":
+		return []string{"Mu", "Alpha"}
+	case "ZeroInflatedPoisson":
+		return []string{"Lambda", "Pi"}
+	case "ZeroInflatedNegativeBinomial":
+		return []string{"Mu", "Alpha", "Pi"}
 	case "StudentsT":
 		return []string{"Mu", "Sigma", "Nu"}
 	case "Uniform":
@@ -0,0 +1,98 @@
+package src
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+func TestPosteriorPredictivePropagatesParameterUncertainty(t *testing.T) {
+	link, _ := IdentityLink(1)
+	p := Posterior{
+		LikelihoodParams: DistributionParams{Dist: "Poisson", Params: map[string]float64{}},
+		Seed:             9,
+	}
+
+	// Slopes spread around 1.5 with intercept 1: at a feature of 4 the rates
+	// are 3, 5, 7, 9 and 11, with mean 7 and variance 8
+	var draws []PosteriorResult
+	for _, slope := range []float64{0.5, 1, 1.5, 2, 2.5} {
+		draws = append(draws, PosteriorResult{Params: []float64{slope, 1}})
+	}
+	plugIn := []PosteriorResult{{Params: []float64{1.5, 1}}}
+
+	n := 20000
+	predictive := p.CalcPosteriorPredictive(draws, []float64{4}, n, link)
+	fixed := p.CalcPosteriorPredictive(plugIn, []float64{4}, n, link)
+	if len(predictive) != n || len(fixed) != n {
+		t.Fatalf("got %d and %d draws, want %d", len(predictive), len(fixed), n)
+	}
+
+	if mean := stat.Mean(predictive, nil); math.Abs(mean-7) > 0.1 {
+		t.Errorf("predictive mean = %.3f, want 7", mean)
+	}
+	// Poisson noise plus the variance of the rate, against Poisson noise alone
+	if v := stat.Variance(predictive, nil); math.Abs(v-15) > 1 {
+		t.Errorf("predictive variance = %.2f, want 15", v)
+	}
+	if v, plug := stat.Variance(predictive, nil), stat.Variance(fixed, nil); !(v > 1.5*plug) {
+		t.Errorf("predictive variance %.2f is not wider than the plug-in variance %.2f", v, plug)
+	}
+
+	// The features of the upcoming game go through the link
+	if mean := stat.Mean(p.CalcPosteriorPredictive(draws, []float64{0}, n, link), nil); math.Abs(mean-1) > 0.05 {
+		t.Errorf("predictive mean at a feature of 0 = %.3f, want 1", mean)
+	}
+}
+
+// seededPredictions samples a Poisson model of series with every chain
+// running concurrently, and draws its posterior predictive
+func seededPredictions(t *testing.T, sampler string, seed uint64) ([]ChainResult, []float64) {
+	series := []float64{2, 0, 3, 1, 4, 0, 2, 5, 1, 3, 2, 4}
+	spec := ModelSpec{Likelihood: "Poisson", Link: "log", Sampler: sampler}
+	spec.Covariates.Lags = 1
+	spec.Settings.GridSize = 15
+	spec.Settings.Steps, spec.Settings.Warmup = 200, 100
+	model, err := spec.Build(series)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := mat.NewDense(len(series)-1, 1, series[:len(series)-1])
+	outputs := series[1:]
+	likelihood := Likelihood{
+		Params:             make([]float64, len(model.Priors)),
+		DistributionParams: model.Likelihood,
+		InputData:          *inputs,
+		OutputData:         *mat.NewVecDense(len(outputs), outputs),
+		Link:               model.Link,
+		LinkJacobian:       model.LinkJacobian,
+	}
+	p := Posterior{
+		Priors:           model.Priors,
+		Data:             *inputs,
+		LikelihoodParams: model.Likelihood,
+		MarkovChain:      model.NewMarkovChain(likelihood),
+		Seed:             seed,
+	}
+	chains := p.CalcPosterior(3)
+	return chains, p.CalcPosteriorPredictive(PoolChains(chains), series[len(series)-1:], 200, model.Link)
+}
+
+func TestSameSeedReproducesPredictions(t *testing.T) {
+	for _, sampler := range []string{"Metropolis", "Adaptive", "NUTS"} {
+		chains, preds := seededPredictions(t, sampler, 42)
+		again, predsAgain := seededPredictions(t, sampler, 42)
+		if !reflect.DeepEqual(chains, again) || !reflect.DeepEqual(preds, predsAgain) {
+			t.Errorf("%s: the same seed gave different results", sampler)
+		}
+
+		other, predsOther := seededPredictions(t, sampler, 43)
+		if reflect.DeepEqual(chains, other) || reflect.DeepEqual(preds, predsOther) {
+			t.Errorf("%s: different seeds gave the same results", sampler)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package src
+
+// CLVResult compares the price a bet was taken at to the closing price of
+// the same market, outcome and point
+type CLVResult struct {
+	Bet          BetOutcome `json:"bet"`
+	ClosingPrice float64    `json:"closingPrice"`
+	ClosingTime  string     `json:"closingTime"`
+	// CLV is price / closing price - 1; positive means the bet beat the close
+	CLV float64 `json:"clv"`
+	// ProbEdge is closing implied probability minus taken implied probability
+	ProbEdge float64 `json:"probEdge"`
+}
+
+// CLVSummary aggregates CLV over a group of bets
+type CLVSummary struct {
+	Count     int     `json:"count"`
+	MeanCLV   float64 `json:"meanCLV"`
+	MeanEdge  float64 `json:"meanProbEdge"`
+	BeatClose float64 `json:"beatClose"`
+}
+
+// CLVReport is the output of the clv command
+type CLVReport struct {
+	Bets        []CLVResult           `json:"bets"`
+	Unmatched   []BetOutcome          `json:"unmatched"`
+	Overall     CLVSummary            `json:"overall"`
+	ByMarket    map[string]CLVSummary `json:"byMarket"`
+	ByBookmaker map[string]CLVSummary `json:"byBookmaker"`
+	ByPlayer    map[string]CLVSummary `json:"byPlayer"`
+}
+
+// ComputeCLV prices every distinct bet in betsPath, or the stored bets when
+// it is empty, against the last stored odds snapshot at or before tip-off of
+// its event. inputs lists the bets and snapshots used, for the manifest.
+func ComputeCLV(store Store, betsPath string) (report CLVReport, inputs []ManifestInput, err error) {
+	var slips []BetSlip
+	betsRef := BetsRef
+	if betsPath != "" {
+		slips, err = ReadBets(betsPath)
+		betsRef = RefFromPath(betsPath)
+	} else {
+		slips, err = store.LoadBets()
+	}
+	if err != nil {
+		return report, nil, err
+	}
+	inputs = append(inputs, InputOf(betsRef, slips))
+
+	seen := make(map[string]bool)
+	for _, slip := range slips {
+		for _, arb := range slip.Bets {
+			bet := arb.Bet
+			key := generateBetKey(bet) + "|" + bet.Bookmaker
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			result, snapshot, ok, err := closingLine(store, bet)
+			if err != nil {
+				return report, nil, err
+			}
+			if !ok {
+				report.Unmatched = append(report.Unmatched, bet)
+				continue
+			}
+			report.Bets = append(report.Bets, result)
+			inputs = MergeInputs(inputs, InputOf(snapshot.Key.SnapshotRef(snapshot.Label, snapshot.Timestamp), snapshot.Event))
+		}
+	}
+
+	report.Overall = summarizeCLV(report.Bets)
+	report.ByMarket = groupCLV(report.Bets, func(r CLVResult) string { return r.Bet.Type })
+	report.ByBookmaker = groupCLV(report.Bets, func(r CLVResult) string {
+		if r.Bet.Bookmaker == "" {
+			return "consensus"
+		}
+		return r.Bet.Bookmaker
+	})
+	report.ByPlayer = groupCLV(report.Bets, func(r CLVResult) string { return r.Bet.Description })
+	return report, inputs, nil
+}
+
+// eventKey identifies a game across bets and odds files
+func eventKey(homeTeam string, awayTeam string, commenceTime string) string {
+	return homeTeam + "|" + awayTeam + "|" + commenceTime
+}
+
+// closingLine finds the closing price of a bet. Bets that record their
+// bookmaker are matched to that book; older bets use the mean closing price
+// across books.
+func closingLine(store Store, bet BetOutcome) (CLVResult, OddsSnapshot, bool, error) {
+	result := CLVResult{Bet: bet}
+
+	if bet.Price <= 0 {
+		return result, OddsSnapshot{}, false, nil
+	}
+	key, ok, err := store.FindEvent("", bet.HomeTeam, bet.AwayTeam, bet.Time)
+	if err != nil || !ok {
+		return result, OddsSnapshot{}, false, err
+	}
+	commence, err := ParseTime(bet.Time)
+	if err != nil {
+		return result, OddsSnapshot{}, false, nil
+	}
+	snapshot, ok, err := store.OddsAt(key, commence)
+	if err != nil || !ok {
+		return result, snapshot, false, err
+	}
+
+	market := bet.Market
+	if market == "" {
+		market = "player_" + bet.Type
+	}
+
+	total, count := 0.0, 0
+	for _, outcome := range FlattenOutcomes(snapshot.Event)[market] {
+		if bet.Bookmaker != "" && outcome.Bookmaker != bet.Bookmaker {
+			continue
+		}
+		if outcome.Name != bet.Name || outcome.Description != bet.Description || !samePoint(outcome.Point, bet.Point) {
+			continue
+		}
+		if outcome.Price <= 0 {
+			continue
+		}
+		total += outcome.Price
+		count++
+	}
+	if count == 0 {
+		return result, snapshot, false, nil
+	}
+
+	result.ClosingPrice = total / float64(count)
+	result.ClosingTime = snapshot.Timestamp.Format("2006-01-02T15:04:05Z")
+	result.CLV = bet.Price/result.ClosingPrice - 1
+	result.ProbEdge = 1/result.ClosingPrice - 1/bet.Price
+	return result, snapshot, true, nil
+}
+
+func samePoint(a *float64, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func summarizeCLV(results []CLVResult) CLVSummary {
+	summary := CLVSummary{Count: len(results)}
+	if len(results) == 0 {
+		return summary
+	}
+	beat := 0
+	for _, r := range results {
+		summary.MeanCLV += r.CLV
+		summary.MeanEdge += r.ProbEdge
+		if r.CLV > 0 {
+			beat++
+		}
+	}
+	n := float64(len(results))
+	summary.MeanCLV /= n
+	summary.MeanEdge /= n
+	summary.BeatClose = float64(beat) / n
+	return summary
+}
+
+func groupCLV(results []CLVResult, key func(CLVResult) string) map[string]CLVSummary {
+	groups := make(map[string][]CLVResult)
+	for _, r := range results {
+		groups[key(r)] = append(groups[key(r)], r)
+	}
+	summaries := make(map[string]CLVSummary, len(groups))
+	for k, g := range groups {
+		summaries[k] = summarizeCLV(g)
+	}
+	return summaries
+}
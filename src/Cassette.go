@@ -0,0 +1,113 @@
+package src
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cassette records HTTP responses to disk or replays them without network
+type Cassette struct {
+	Mode string // "record" or "replay"
+	Dir  string
+}
+
+// CassetteEntry is one stored request/response pair
+type CassetteEntry struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// secretParams are dropped from URLs before they are used as cassette keys
+var secretParams = []string{"apiKey", "apikey", "api_key", "key"}
+
+var cassette *Cassette
+
+// UseCassette switches the fetch layer into record or replay mode. An empty
+// mode turns cassettes off.
+func UseCassette(mode string, dir string) error {
+	switch mode {
+	case "":
+		cassette = nil
+		return nil
+	case "record":
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %v", err)
+		}
+	case "replay":
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("cassette directory %s does not exist", dir)
+		}
+	default:
+		return fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	cassette = &Cassette{Mode: mode, Dir: dir}
+	return nil
+}
+
+// NormalizeURL lowercases scheme and host, removes credentials from the
+// query and sorts the remaining parameters so equal requests share a key
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %v", raw, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+
+	query := u.Query()
+	for _, param := range secretParams {
+		query.Del(param)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func (c *Cassette) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load returns the recorded response for a URL
+func (c *Cassette) Load(rawURL string) (CassetteEntry, error) {
+	var entry CassetteEntry
+
+	key, err := NormalizeURL(rawURL)
+	if err != nil {
+		return entry, err
+	}
+
+	raw, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entry, fmt.Errorf("no recorded response for %s in %s", key, c.Dir)
+		}
+		return entry, fmt.Errorf("failed to read cassette entry: %v", err)
+	}
+
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entry, fmt.Errorf("failed to parse cassette entry for %s: %v", key, err)
+	}
+	return entry, nil
+}
+
+// Save stores a response under the normalized URL
+func (c *Cassette) Save(rawURL string, status int, body string) error {
+	key, err := NormalizeURL(rawURL)
+	if err != nil {
+		return err
+	}
+
+	entry := CassetteEntry{URL: key, Status: status, Body: body}
+	return SaveToFile(entry, c.Dir, filepath.Base(c.path(key)))
+}
@@ -0,0 +1,169 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DataDir is the root of the on-disk data tree. It is overridden by the
+// data_dir config setting or the BETTERBETTER_DATA_DIR environment variable.
+var DataDir = "data"
+
+// DefaultConfigFile is read when no config path is given and it exists
+const DefaultConfigFile = "betterbetter.yaml"
+
+// Config holds settings shared by every command
+type Config struct {
+	DataDir      string                       `yaml:"data_dir"`
+	DefaultSport string                       `yaml:"default_sport"`
+	Sports       []string                     `yaml:"sports"` // extra sport definition files
+	Providers    map[string]ProviderConfig    `yaml:"providers"`
+	Commands     map[string]map[string]string `yaml:"commands"`
+	Storage      StorageConfig                `yaml:"storage"`
+}
+
+// StorageConfig selects the storage backend
+type StorageConfig struct {
+	Backend string `yaml:"backend"` // json or sqlite
+	Path    string `yaml:"path"`    // sqlite database file
+}
+
+// ProviderConfig holds credentials and endpoints for one provider
+type ProviderConfig struct {
+	Key      string            `yaml:"key"`
+	BaseURL  string            `yaml:"base_url"`
+	BaseURLs map[string]string `yaml:"base_urls"`
+	Options  map[string]string `yaml:"options"`
+
+	RateLimit float64 `yaml:"rate_limit"` // requests per second, 0 for unlimited
+	Burst     int     `yaml:"burst"`
+	Timeout   float64 `yaml:"timeout"` // seconds
+	Retries   int     `yaml:"retries"`
+	Budget    float64 `yaml:"budget"` // quota units this run may spend, 0 for unlimited
+}
+
+// Configurable is implemented by providers that accept a ProviderConfig
+type Configurable interface {
+	Configure(cfg ProviderConfig) error
+}
+
+// LoadConfig reads the YAML config at path and applies environment
+// overrides. An empty path falls back to BETTERBETTER_CONFIG and then to
+// DefaultConfigFile; a missing default file yields an empty config.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("BETTERBETTER_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = DefaultConfigFile
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			raw = nil
+		} else {
+			return nil, fmt.Errorf("failed to read config %s: %v", path, err)
+		}
+	}
+
+	if len(raw) > 0 {
+		if err := yaml.Unmarshal(raw, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+		}
+	}
+
+	cfg.applyEnv()
+	return cfg, nil
+}
+
+// applyEnv overrides file settings with BETTERBETTER_* environment variables.
+// Provider keys use BETTERBETTER_<PROVIDER>_KEY, e.g. BETTERBETTER_API_SPORTS_KEY.
+func (c *Config) applyEnv() {
+	if v := os.Getenv("BETTERBETTER_DATA_DIR"); v != "" {
+		c.DataDir = v
+	}
+	if v := os.Getenv("BETTERBETTER_SPORT"); v != "" {
+		c.DefaultSport = v
+	}
+	if v := os.Getenv("BETTERBETTER_STORAGE"); v != "" {
+		c.Storage.Backend = v
+	}
+
+	if c.Providers == nil {
+		c.Providers = map[string]ProviderConfig{}
+	}
+	for _, name := range ProviderNames() {
+		if v := os.Getenv(envName(name, "KEY")); v != "" {
+			pc := c.Providers[name]
+			pc.Key = v
+			c.Providers[name] = pc
+		}
+		if v := os.Getenv(envName(name, "BASE_URL")); v != "" {
+			pc := c.Providers[name]
+			pc.BaseURL = v
+			c.Providers[name] = pc
+		}
+	}
+}
+
+// Apply pushes the config into package state, the sport registry and
+// registered providers
+func (c *Config) Apply() error {
+	if c.DataDir != "" {
+		DataDir = c.DataDir
+	}
+	if c.Storage.Backend != "" {
+		StorageBackend = c.Storage.Backend
+	}
+	if c.Storage.Path != "" {
+		StoragePath = c.Storage.Path
+	}
+
+	for _, path := range c.Sports {
+		if err := LoadSportFile(path); err != nil {
+			return err
+		}
+	}
+
+	for name, pc := range c.Providers {
+		p, err := GetProvider(name)
+		if err != nil {
+			return err
+		}
+		ConfigureLimits(name, pc)
+		configurable, ok := p.(Configurable)
+		if !ok {
+			return fmt.Errorf("provider %s does not accept configuration", name)
+		}
+		if err := configurable.Configure(pc); err != nil {
+			return fmt.Errorf("failed to configure provider %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// CommandDefaults returns the flag defaults configured for a command
+func (c *Config) CommandDefaults(command string) map[string]string {
+	defaults := map[string]string{}
+	if c.DefaultSport != "" {
+		defaults["sport"] = c.DefaultSport
+	}
+	for flag, value := range c.Commands[command] {
+		defaults[flag] = value
+	}
+	return defaults
+}
+
+func envName(provider string, suffix string) string {
+	name := strings.ToUpper(strings.ReplaceAll(provider, "-", "_"))
+	return "BETTERBETTER_" + name + "_" + suffix
+}
@@ -0,0 +1,151 @@
+package src
+
+import (
+	"math"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// NegativeBinomial is the count distribution with mean Mu and dispersion
+// Alpha, a Poisson whose rate is Gamma distributed; its variance is
+// Mu + Mu^2/Alpha
+type NegativeBinomial struct {
+	Mu    float64
+	Alpha float64
+	Src   rand.Source
+}
+
+func (n NegativeBinomial) LogProb(x float64) float64 {
+	if x < 0 || x != math.Floor(x) {
+		return math.Inf(-1)
+	}
+	return UVNegativeBinomialLogLikelihood(n.Mu, n.Alpha, []float64{x})
+}
+
+func (n NegativeBinomial) Prob(x float64) float64 {
+	return math.Exp(n.LogProb(x))
+}
+
+func (n NegativeBinomial) CDF(x float64) float64 {
+	return countCDF(n.Prob, x)
+}
+
+func (n NegativeBinomial) Rand() float64 {
+	rate := distuv.Gamma{Alpha: n.Alpha, Beta: n.Alpha / n.Mu, Src: n.Src}.Rand()
+	return distuv.Poisson{Lambda: rate, Src: n.Src}.Rand()
+}
+
+// countDistribution is a distribution over counts that can be zero-inflated
+type countDistribution interface {
+	Distribution
+	LogProb(float64) float64
+}
+
+// ZeroInflated is a count distribution that is zero with extra probability
+// Pi: a zero with probability Pi, otherwise a draw from Count
+type ZeroInflated struct {
+	Pi    float64
+	Count countDistribution
+	Src   rand.Source
+}
+
+func (z ZeroInflated) LogProb(x float64) float64 {
+	if x == 0 {
+		return math.Log(z.Pi + (1-z.Pi)*math.Exp(z.Count.LogProb(0)))
+	}
+	return math.Log1p(-z.Pi) + z.Count.LogProb(x)
+}
+
+func (z ZeroInflated) Prob(x float64) float64 {
+	return math.Exp(z.LogProb(x))
+}
+
+func (z ZeroInflated) CDF(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	return z.Pi + (1-z.Pi)*z.Count.CDF(x)
+}
+
+func (z ZeroInflated) Rand() float64 {
+	var u float64
+	if z.Src == nil {
+		u = rand.Float64()
+	} else {
+		u = rand.New(z.Src).Float64()
+	}
+	if u < z.Pi {
+		return 0
+	}
+	return z.Count.Rand()
+}
+
+// countCDF sums prob over the counts up to x
+func countCDF(prob func(float64) float64, x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	sum := 0.0
+	for k := 0.0; k <= math.Floor(x); k++ {
+		sum += prob(k)
+	}
+	return math.Min(sum, 1)
+}
+
+// UVZeroInflatedPoissonLogLikelihood is the log likelihood of counts that
+// are zero with probability pi and Poisson(lambda) otherwise
+func UVZeroInflatedPoissonLogLikelihood(lambda float64, pi float64, data []float64) float64 {
+	sum := 0.0
+	for _, d := range data {
+		if d == 0 {
+			sum += math.Log(pi + (1-pi)*math.Exp(-lambda))
+			continue
+		}
+		lgD, _ := math.Lgamma(d + 1)
+		sum += math.Log1p(-pi) + d*math.Log(lambda) - lambda - lgD
+	}
+	return sum
+}
+
+// UVZeroInflatedNegativeBinomialLogLikelihood is the log likelihood of
+// counts that are zero with probability pi and NegativeBinomial(mu, alpha)
+// otherwise
+func UVZeroInflatedNegativeBinomialLogLikelihood(mu float64, alpha float64, pi float64, data []float64) float64 {
+	sum := 0.0
+	for _, d := range data {
+		if d == 0 {
+			sum += math.Log(pi + (1-pi)*math.Pow(alpha/(alpha+mu), alpha))
+			continue
+		}
+		sum += math.Log1p(-pi) + UVNegativeBinomialLogLikelihood(mu, alpha, []float64{d})
+	}
+	return sum
+}
+
+// UVBinomialLogLikelihood is the log likelihood of successes out of n
+// trials with success probability p
+func UVBinomialLogLikelihood(n float64, p float64, data []float64) float64 {
+	lgN, _ := math.Lgamma(n + 1)
+	sum := 0.0
+	for _, d := range data {
+		if d < 0 || d > n || d != math.Floor(d) {
+			return math.Inf(-1)
+		}
+		lgD, _ := math.Lgamma(d + 1)
+		lgRest, _ := math.Lgamma(n - d + 1)
+		sum += lgN - lgD - lgRest + d*math.Log(p) + (n-d)*math.Log1p(-p)
+	}
+	return sum
+}
+
+// IsCountLikelihood reports whether a likelihood models counts, which bayes
+// fits with a log link
+func IsCountLikelihood(dist string) bool {
+	switch dist {
+	case "Poisson", "NegativeBinomial", "ZeroInflatedPoisson", "ZeroInflatedNegativeBinomial":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,451 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Diagnostics summarizes how well the chains of one posterior mixed, following
+// Vehtari et al. (2021), "Rank-normalization, folding, and localization"
+type Diagnostics struct {
+	Chains      int                `json:"chains"`
+	Draws       int                `json:"draws"`       // per chain
+	Acceptance  float64            `json:"acceptance"`  // share of transitions that moved, over every chain
+	Divergences int                `json:"divergences"` // divergent transitions of gradient-based samplers
+	Params      []ParamDiagnostics `json:"params"`
+}
+
+// ParamDiagnostics are the diagnostics of one parameter
+type ParamDiagnostics struct {
+	Param      int
+	Name       string  // the model's name of the parameter, such as lag1 or intercept
+	Acceptance float64 // share of transitions that moved this parameter
+	RHat       float64 // rank-normalized split R-hat, the larger of bulk and tail
+	BulkESS    float64
+	TailESS    float64 // the smaller of the 5% and 95% quantile ESS
+	Autocorr   float64 // lag-1 autocorrelation averaged over chains
+}
+
+// MarshalJSON writes statistics that are not finite, such as the R-hat of a
+// parameter that never moved, as null
+func (p ParamDiagnostics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Param      int      `json:"param"`
+		Name       string   `json:"name"`
+		Acceptance *float64 `json:"acceptance"`
+		RHat       *float64 `json:"rhat"`
+		BulkESS    *float64 `json:"bulkEss"`
+		TailESS    *float64 `json:"tailEss"`
+		Autocorr   *float64 `json:"autocorr"`
+	}{p.Param, p.Name, finite(p.Acceptance), finite(p.RHat), finite(p.BulkESS), finite(p.TailESS), finite(p.Autocorr)})
+}
+
+func finite(x float64) *float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil
+	}
+	return &x
+}
+
+// Label is the parameter's name, or its index when the model did not name it
+func (p ParamDiagnostics) Label() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return fmt.Sprintf("param %d", p.Param)
+}
+
+// DiagnosticThresholds are the limits a posterior has to meet; zero
+// disables a check, except for MaxDivergences where zero flags any
+// divergent transition and a negative value disables the check
+type DiagnosticThresholds struct {
+	MaxRHat        float64
+	MinESS         float64
+	MinAcceptance  float64
+	MaxDivergences int
+}
+
+// Diagnose computes the diagnostics of every parameter from the draws of
+// each chain, naming the parameters by names. Chains are trimmed to the
+// shortest one.
+func Diagnose(chains []ChainResult, names []string) Diagnostics {
+	d := Diagnostics{Chains: len(chains)}
+	if len(chains) == 0 {
+		return d
+	}
+
+	draws := len(chains[0].Samples)
+	for _, c := range chains {
+		draws = min(draws, len(c.Samples))
+	}
+	d.Draws = draws
+	if draws == 0 {
+		return d
+	}
+
+	for _, c := range chains {
+		d.Divergences += c.Stats.Divergences
+	}
+
+	moves := 0
+	for _, c := range chains {
+		for i := 1; i < draws; i++ {
+			if !equalParams(c.Samples[i].Params, c.Samples[i-1].Params) {
+				moves++
+			}
+		}
+	}
+	if draws > 1 {
+		d.Acceptance = float64(moves) / float64(len(chains)*(draws-1))
+	}
+
+	for p := range chains[0].Samples[0].Params {
+		series := make([][]float64, len(chains))
+		for i, c := range chains {
+			series[i] = make([]float64, draws)
+			for j := 0; j < draws; j++ {
+				series[i][j] = c.Samples[j].Params[p]
+			}
+		}
+		param := diagnoseParam(p, series)
+		if p < len(names) {
+			param.Name = names[p]
+		}
+		d.Params = append(d.Params, param)
+	}
+	return d
+}
+
+// Check lists the thresholds the posterior does not meet
+func (d Diagnostics) Check(t DiagnosticThresholds) []string {
+	var problems []string
+	if t.MinAcceptance > 0 && !(d.Acceptance >= t.MinAcceptance) {
+		problems = append(problems, fmt.Sprintf("acceptance %.3f below %.3f", d.Acceptance, t.MinAcceptance))
+	}
+	if t.MaxDivergences >= 0 && d.Divergences > t.MaxDivergences {
+		problems = append(problems, fmt.Sprintf("%d divergent transitions above %d", d.Divergences, t.MaxDivergences))
+	}
+	for _, p := range d.Params {
+		if t.MaxRHat > 0 && !(p.RHat <= t.MaxRHat) {
+			problems = append(problems, fmt.Sprintf("%s R-hat %.3f above %.3f", p.Label(), p.RHat, t.MaxRHat))
+		}
+		if t.MinESS > 0 && !(p.BulkESS >= t.MinESS) {
+			problems = append(problems, fmt.Sprintf("%s bulk ESS %.0f below %.0f", p.Label(), p.BulkESS, t.MinESS))
+		}
+		if t.MinESS > 0 && !(p.TailESS >= t.MinESS) {
+			problems = append(problems, fmt.Sprintf("%s tail ESS %.0f below %.0f", p.Label(), p.TailESS, t.MinESS))
+		}
+	}
+	return problems
+}
+
+func diagnoseParam(param int, chains [][]float64) ParamDiagnostics {
+	split := splitChains(chains)
+
+	lag1 := 0.0
+	moves, transitions := 0, 0
+	for _, c := range chains {
+		acf := Autocorrelation(c, 1)
+		if len(acf) > 1 {
+			lag1 += acf[1]
+		}
+		for i := 1; i < len(c); i++ {
+			if c[i] != c[i-1] {
+				moves++
+			}
+			transitions++
+		}
+	}
+
+	acceptance := 0.0
+	if transitions > 0 {
+		acceptance = float64(moves) / float64(transitions)
+	}
+
+	return ParamDiagnostics{
+		Param:      param,
+		Acceptance: acceptance,
+		RHat:       SplitRHat(chains),
+		BulkESS:    EffectiveSampleSize(zScale(split)),
+		TailESS:    TailESS(chains),
+		Autocorr:   lag1 / float64(len(chains)),
+	}
+}
+
+// SplitRHat is the rank-normalized split R-hat of a parameter: the larger
+// of the R-hat of the rank-normalized draws and of their folded ranks
+func SplitRHat(chains [][]float64) float64 {
+	split := splitChains(chains)
+	bulk := rHat(zScale(split))
+	tail := rHat(zScale(fold(split)))
+	return math.Max(bulk, tail)
+}
+
+// TailESS is the smaller of the effective sample sizes of the 5% and 95%
+// quantiles
+func TailESS(chains [][]float64) float64 {
+	var pooled []float64
+	for _, c := range chains {
+		pooled = append(pooled, c...)
+	}
+	sort.Float64s(pooled)
+	lower := stat.Quantile(0.05, stat.Empirical, pooled, nil)
+	upper := stat.Quantile(0.95, stat.Empirical, pooled, nil)
+
+	split := splitChains(chains)
+	below := make([][]float64, len(split))
+	above := make([][]float64, len(split))
+	for i, c := range split {
+		below[i] = make([]float64, len(c))
+		above[i] = make([]float64, len(c))
+		for j, x := range c {
+			if x <= lower {
+				below[i][j] = 1
+			}
+			if x >= upper {
+				above[i][j] = 1
+			}
+		}
+	}
+	return math.Min(EffectiveSampleSize(below), EffectiveSampleSize(above))
+}
+
+// EffectiveSampleSize estimates the number of independent draws the chains
+// are worth, truncating the autocorrelations with Geyer's initial monotone
+// sequence. It is NaN when the draws do not vary.
+func EffectiveSampleSize(chains [][]float64) float64 {
+	m := len(chains)
+	if m == 0 {
+		return math.NaN()
+	}
+	n := len(chains[0])
+	if n < 4 {
+		return math.NaN()
+	}
+
+	acov := make([][]float64, m)
+	means := make([]float64, m)
+	meanVar := 0.0
+	for i, c := range chains {
+		acov[i] = autocovariance(c)
+		means[i] = stat.Mean(c, nil)
+		meanVar += acov[i][0] * float64(n) / float64(n-1)
+	}
+	meanVar /= float64(m)
+
+	varPlus := meanVar * float64(n-1) / float64(n)
+	if m > 1 {
+		varPlus += stat.Variance(means, nil)
+	}
+	if varPlus <= 0 || math.IsNaN(varPlus) {
+		return math.NaN()
+	}
+
+	acovMean := func(t int) float64 {
+		sum := 0.0
+		for i := range acov {
+			sum += acov[i][t]
+		}
+		return sum / float64(m)
+	}
+
+	rho := make([]float64, n)
+	rhoEven := 1.0
+	rhoOdd := 1 - (meanVar-acovMean(1))/varPlus
+	rho[0] = rhoEven
+	rho[1] = rhoOdd
+
+	// Geyer's initial positive sequence
+	t := 0
+	for t < n-4 && rhoEven+rhoOdd > 0 {
+		t += 2
+		rhoEven = 1 - (meanVar-acovMean(t))/varPlus
+		rhoOdd = 1 - (meanVar-acovMean(t+1))/varPlus
+		if rhoEven+rhoOdd >= 0 {
+			rho[t] = rhoEven
+			rho[t+1] = rhoOdd
+		}
+	}
+	maxT := t
+	if rhoEven > 0 {
+		rho[maxT+1] = rhoEven
+	}
+
+	// made monotone
+	for t := 1; t <= maxT-3; t += 2 {
+		if rho[t+1]+rho[t+2] > rho[t-1]+rho[t] {
+			rho[t+1] = (rho[t-1] + rho[t]) / 2
+			rho[t+2] = rho[t+1]
+		}
+	}
+
+	total := float64(m * n)
+	tau := -1 + 2*Sum(rho[:maxT]) + rho[maxT+1]
+	return math.Min(total/tau, total*math.Log10(total))
+}
+
+// Autocorrelation returns the autocorrelation of x at lags 0 to maxLag
+func Autocorrelation(x []float64, maxLag int) []float64 {
+	acov := autocovariance(x)
+	if maxLag >= len(acov) {
+		maxLag = len(acov) - 1
+	}
+	acf := make([]float64, maxLag+1)
+	for t := range acf {
+		if acov[0] > 0 {
+			acf[t] = acov[t] / acov[0]
+		}
+	}
+	return acf
+}
+
+// autocovariance returns the biased autocovariance of x at every lag,
+// computed with a zero-padded FFT
+func autocovariance(x []float64) []float64 {
+	n := len(x)
+	if n == 0 {
+		return nil
+	}
+	size := 1
+	for size < 2*n {
+		size *= 2
+	}
+
+	mean := stat.Mean(x, nil)
+	padded := make([]float64, size)
+	for i, v := range x {
+		padded[i] = v - mean
+	}
+
+	fft := fourier.NewFFT(size)
+	coeff := fft.Coefficients(nil, padded)
+	for i, c := range coeff {
+		coeff[i] = complex(real(c)*real(c)+imag(c)*imag(c), 0)
+	}
+	seq := fft.Sequence(nil, coeff)
+
+	acov := make([]float64, n)
+	for t := range acov {
+		acov[t] = seq[t] / float64(size) / float64(n)
+	}
+	return acov
+}
+
+// rHat is the potential scale reduction of chains of equal length
+func rHat(chains [][]float64) float64 {
+	m := len(chains)
+	if m == 0 || len(chains[0]) < 2 {
+		return math.NaN()
+	}
+	n := float64(len(chains[0]))
+
+	means := make([]float64, m)
+	within := 0.0
+	for i, c := range chains {
+		means[i] = stat.Mean(c, nil)
+		within += stat.Variance(c, nil)
+	}
+	within /= float64(m)
+	between := 0.0
+	if m > 1 {
+		between = n * stat.Variance(means, nil)
+	}
+	if within <= 0 {
+		// stuck chains: identical draws are NaN, chains stuck apart never mix
+		if between > 0 {
+			return math.Inf(1)
+		}
+		return math.NaN()
+	}
+	varPlus := (n-1)/n*within + between/n
+	return math.Sqrt(varPlus / within)
+}
+
+// splitChains halves every chain, dropping the middle draw of odd lengths
+func splitChains(chains [][]float64) [][]float64 {
+	split := make([][]float64, 0, 2*len(chains))
+	for _, c := range chains {
+		half := len(c) / 2
+		split = append(split, c[:half], c[len(c)-half:])
+	}
+	return split
+}
+
+// zScale replaces the draws by the normal quantiles of their pooled ranks,
+// averaging the ranks of ties
+func zScale(chains [][]float64) [][]float64 {
+	type draw struct {
+		value      float64
+		chain, pos int
+	}
+	var pooled []draw
+	for i, c := range chains {
+		for j, x := range c {
+			pooled = append(pooled, draw{x, i, j})
+		}
+	}
+	sort.SliceStable(pooled, func(a, b int) bool { return pooled[a].value < pooled[b].value })
+
+	scaled := make([][]float64, len(chains))
+	for i, c := range chains {
+		scaled[i] = make([]float64, len(c))
+	}
+	total := float64(len(pooled))
+	for start := 0; start < len(pooled); {
+		end := start
+		for end < len(pooled) && pooled[end].value == pooled[start].value {
+			end++
+		}
+		rank := float64(start+end+1) / 2 // average of the ranks start+1..end
+		z := distuv.UnitNormal.Quantile((rank - 3.0/8.0) / (total + 1.0/4.0))
+		for _, d := range pooled[start:end] {
+			scaled[d.chain][d.pos] = z
+		}
+		start = end
+	}
+	return scaled
+}
+
+// fold replaces the draws by their distance from the pooled median
+func fold(chains [][]float64) [][]float64 {
+	var pooled []float64
+	for _, c := range chains {
+		pooled = append(pooled, c...)
+	}
+	sort.Float64s(pooled)
+	median := stat.Quantile(0.5, stat.Empirical, pooled, nil)
+
+	folded := make([][]float64, len(chains))
+	for i, c := range chains {
+		folded[i] = make([]float64, len(c))
+		for j, x := range c {
+			folded[i][j] = math.Abs(x - median)
+		}
+	}
+	return folded
+}
+
+func equalParams(a []float64, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DiagnosticsRecord is the diagnostics of a metric's posterior as its
+// predictions' manifest reports them, with the thresholds it failed
+type DiagnosticsRecord struct {
+	Diagnostics
+	Problems []string `json:"problems,omitempty"`
+}
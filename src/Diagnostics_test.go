@@ -0,0 +1,117 @@
+package src
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// normalChains draws independent standard normal chains
+func normalChains(seed uint64, chains int, draws int) [][]float64 {
+	rng := NewRand(seed)
+	out := make([][]float64, chains)
+	for c := range out {
+		out[c] = make([]float64, draws)
+		for i := range out[c] {
+			out[c][i] = rng.NormFloat64()
+		}
+	}
+	return out
+}
+
+func TestIIDChainsConverge(t *testing.T) {
+	chains := normalChains(1, 4, 1000)
+	n := 4000.0
+
+	if r := SplitRHat(chains); math.Abs(r-1) > 0.01 {
+		t.Errorf("R-hat of iid chains = %.4f, want about 1", r)
+	}
+	if ess := EffectiveSampleSize(chains); ess < 0.8*n || ess > 1.2*n {
+		t.Errorf("bulk ESS of %v iid draws = %.0f", n, ess)
+	}
+	if ess := TailESS(chains); ess < 0.7*n || ess > 1.3*n {
+		t.Errorf("tail ESS of %v iid draws = %.0f", n, ess)
+	}
+}
+
+func TestAutocorrelatedChainsHaveFewerEffectiveDraws(t *testing.T) {
+	// AR(1) chains with coefficient phi have ESS = N (1 - phi) / (1 + phi)
+	phi := 0.8
+	chains := normalChains(2, 4, 5000)
+	for _, c := range chains {
+		for i := 1; i < len(c); i++ {
+			c[i] = phi*c[i-1] + math.Sqrt(1-phi*phi)*c[i]
+		}
+	}
+	want := 20000 * (1 - phi) / (1 + phi)
+	if ess := EffectiveSampleSize(chains); ess < 0.75*want || ess > 1.25*want {
+		t.Errorf("ESS of AR(%.1f) chains = %.0f, want about %.0f", phi, ess, want)
+	}
+	if acf := Autocorrelation(chains[0], 1); math.Abs(acf[1]-phi) > 0.05 {
+		t.Errorf("lag-1 autocorrelation = %.3f, want about %.1f", acf[1], phi)
+	}
+}
+
+func TestSeparatedChainsFailRHat(t *testing.T) {
+	chains := normalChains(3, 4, 1000)
+	for i := range chains[0] {
+		chains[0][i] += 3
+	}
+	if r := SplitRHat(chains); r < 1.1 {
+		t.Errorf("R-hat of a chain stuck elsewhere = %.3f, want above 1.1", r)
+	}
+}
+
+func TestDiagnoseNamesParamsAndAcceptance(t *testing.T) {
+	// A Gibbs-style chain: the first parameter moves on every draw, the
+	// second drifts on every other draw
+	chains := make([]ChainResult, 2)
+	for c := range chains {
+		for i := 0; i < 101; i++ {
+			chains[c].Samples = append(chains[c].Samples, PosteriorResult{Params: []float64{float64(i % 7), float64(i / 2)}})
+		}
+	}
+	d := Diagnose(chains, []string{"lag1", "intercept"})
+
+	if d.Acceptance != 1 {
+		t.Errorf("overall acceptance = %.3f, want 1", d.Acceptance)
+	}
+	want := []struct {
+		name       string
+		acceptance float64
+	}{{"lag1", 1}, {"intercept", 0.5}}
+	for i, w := range want {
+		p := d.Params[i]
+		if p.Label() != w.name || p.Acceptance != w.acceptance {
+			t.Errorf("param %d = %s with acceptance %.3f, want %s with %.3f", i, p.Label(), p.Acceptance, w.name, w.acceptance)
+		}
+	}
+
+	problems := d.Check(DiagnosticThresholds{MaxRHat: 1.01, MaxDivergences: -1})
+	if len(problems) == 0 || !strings.HasPrefix(problems[len(problems)-1], "intercept R-hat") {
+		t.Errorf("problems %q do not name the parameter", problems)
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	d := Diagnostics{
+		Acceptance:  0.2,
+		Divergences: 3,
+		Params:      []ParamDiagnostics{{Param: 0, RHat: 1.05, BulkESS: 100, TailESS: 500}},
+	}
+	cases := []struct {
+		name       string
+		thresholds DiagnosticThresholds
+		problems   int
+	}{
+		{"every check", DiagnosticThresholds{MaxRHat: 1.01, MinESS: 400, MinAcceptance: 0.3, MaxDivergences: 0}, 4},
+		{"disabled", DiagnosticThresholds{MaxDivergences: -1}, 0},
+		{"divergences allowed", DiagnosticThresholds{MaxDivergences: 3}, 0},
+		{"divergences over the limit", DiagnosticThresholds{MaxDivergences: 2}, 1},
+	}
+	for _, c := range cases {
+		if got := d.Check(c.thresholds); len(got) != c.problems {
+			t.Errorf("%s: got problems %q, want %d", c.name, got, c.problems)
+		}
+	}
+}
@@ -2,166 +2,134 @@ package src
 
 import (
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 )
 
+// FetchData fetches teams, players, statistics or games from the default
+// stats provider. It is kept for callers that predate the Provider interface.
 func FetchData(sport string, requestType string, args map[string]string) string {
-	var url string = ""
-
-	if sport == "nba"{
-		fmt.Println("Fetching NBA data")
-		url = "https://v2.nba.api-sports.io"
-	}
-	if sport == "nfl"{
-		fmt.Println("Fetching NFL data")
-		url = "https://v1.american-football.api-sports.io"
+	provider, err := GetProvider("api-sports")
+	if err != nil {
+		fmt.Println(err)
+		return ""
 	}
 
-	if requestType == "team"{
-		url = url + "/teams?"
-	}
-	if requestType == "player"{
-		url = url + "/players?"
-	}
-	if requestType == "team-stats" || requestType == "player-stats" {
-		url = url + "/players/statistics?"
-	}
-	if requestType == "game"{
-		url = url + "/games?"
+	body, err := FetchFromProvider(provider, sport, requestType, args)
+	if err != nil {
+		fmt.Println(err)
+		return ""
 	}
+	return body
+}
 
-
-	fmt.Println(url)
-
-	if len(args) == 0 {
-		url = url[0:len(url)-1]
-	} else {
-		var index = 1
-		for key, value := range args {
-			if index == 1 {
-				url = url + key + "=" + value
-			} else {
-				url = url + "&" + key + "=" + value
-			}
-			index = index + 1
-		}
+// FetchFromProvider dispatches a fetchdata request type to the matching
+// Provider method
+func FetchFromProvider(p Provider, sport string, requestType string, args map[string]string) (string, error) {
+	switch requestType {
+	case "team":
+		return p.Teams(sport, args)
+	case "player":
+		return p.Players(sport, args)
+	case "team-stats", "player-stats":
+		return p.PlayerGameStats(sport, args)
+	case "game":
+		return p.Games(sport, args)
+	default:
+		return "", fmt.Errorf("unknown request type %q", requestType)
 	}
-
-	fmt.Println(url)
-
-	
-  method := "GET"
-
-  client := &http.Client {}
-
-  req, err := http.NewRequest(method, url, nil)
-
-  if err != nil {
-    fmt.Println(err)
-  }
-  req.Header.Add("x-rapidapi-key", "b6b0dbc354837ac6cfcaf07693d41da2")
-  req.Header.Add("x-rapidapi-host", "v2.nba.api-sports.io")
-
-  res, err := client.Do(req)
-  if err != nil {
-    fmt.Println(err) 
-  }
-  defer res.Body.Close()
-
-  body, err := io.ReadAll(res.Body)
-  if err != nil {
-    fmt.Println(err)
-  }
-  
-	return string(body)
-
 }
 
-
+// FetchGames fetches the historical events list from the default odds provider
 func FetchGames(date string, sport string) string {
-	url := ""
-	
-	if sport == "nba" {
-		url = "https://api.the-odds-api.com/v4/historical/sports/basketball_nba/events?"
-	}
-
-	url += "apiKey=8a2e6be65caa1f5af89fca660c4e7eaa"
-
-	url += "&date=" + date
-
-	fmt.Println(url)
-
-	client := &http.Client {}
-
-	req, err := http.NewRequest("GET", url, nil)
-
+	provider, err := GetProvider("the-odds-api")
 	if err != nil {
 		fmt.Println(err)
+		return ""
 	}
 
-	res, err := client.Do(req)
-
+	body, err := provider.OddsEvents(sport, date)
 	if err != nil {
 		fmt.Println(err)
+		return ""
 	}
-
-	fmt.Println(res)
-
-	defer res.Body.Close()
-
-	body, err := io.ReadAll(res.Body)
-  if err != nil {
-    fmt.Println(err)
-  }
-  
-	responseString := string(body)
-
-	return responseString
-
+	return body
 }
 
+// FetchOdds fetches historical odds for one event from the default odds provider
 func FetchOdds(date string, sport string, id string) string {
-	url := ""
-
-	if sport == "nba" {
-		url = "https://api.the-odds-api.com/v4/historical/sports/basketball_nba/events"
+	provider, err := GetProvider("the-odds-api")
+	if err != nil {
+		fmt.Println(err)
+		return ""
 	}
 
-	url += "/" + id + "/odds?apiKey=8a2e6be65caa1f5af89fca660c4e7eaa"
-
-	url += "&date=" + date + "&regions=us" + "&markets=player_points,player_rebounds,player_assists,player_blocks,player_steals,"
-
-	url += "player_turnovers,h2h,spreads,totals,player_blocks_steals,player_points_rebounds,player_points_assists,"
-
-	url += "player_rebounds_assists,player_points_rebounds_assists,player_first_basket,player_double_double,player_triple_double"
-
-	fmt.Println(url)
-
-	client := &http.Client {}
-
-	req, err := http.NewRequest("GET", url, nil)
-
+	body, err := provider.EventOdds(sport, date, id)
 	if err != nil {
 		fmt.Println(err)
+		return ""
 	}
+	return body
+}
 
-	res, err := client.Do(req)
+// encodeQuery renders args as a query string with keys in sorted order
+func encodeQuery(args map[string]string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	values := url.Values{}
+	for key, value := range args {
+		values.Set(key, value)
+	}
+	return "?" + values.Encode()
+}
 
-	if err != nil {
-		fmt.Println(err)
+// requireKey fails when a provider has no credentials, unless responses are
+// being replayed from a cassette and the key would never be sent
+func requireKey(provider string, key string) error {
+	if key != "" || (cassette != nil && cassette.Mode == "replay") {
+		return nil
 	}
+	return fmt.Errorf("%s: no api key configured (set providers.%s.key or %s)", provider, provider, envName(provider, "KEY"))
+}
 
-	fmt.Println(res)
+// httpGet fetches url on behalf of provider and returns the body of a 2xx
+// response. Cassettes, when enabled, sit in front of the network.
+func httpGet(provider string, url string, headers map[string]string) (string, error) {
+	// Log the request without its credentials
+	if logged, err := NormalizeURL(url); err == nil {
+		fmt.Println(logged)
+	}
 
-	defer res.Body.Close()
+	var status int
+	var body string
+	if cassette != nil && cassette.Mode == "replay" {
+		entry, err := cassette.Load(url)
+		if err != nil {
+			return "", err
+		}
+		status, body = entry.Status, entry.Body
+	} else {
+		var err error
+		status, body, err = doRequest(provider, url, headers)
+		if err != nil {
+			return "", err
+		}
+		if cassette != nil && cassette.Mode == "record" {
+			if err := cassette.Save(url, status, body); err != nil {
+				return "", fmt.Errorf("failed to record response: %v", err)
+			}
+		}
+	}
 
-	body, err := io.ReadAll(res.Body)
-  if err != nil {
-    fmt.Println(err)
-  }
-  
-	responseString := string(body)
+	if status < 200 || status >= 300 {
+		return "", fmt.Errorf("%s returned status %d: %s", provider, status, truncate(body, 200))
+	}
+	return body, nil
+}
 
-	return responseString	
-}
\ No newline at end of file
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,335 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduledGame is one game of a team schedule, whatever the sport
+type ScheduledGame struct {
+	ID       int
+	Start    string
+	Finished bool
+	HomeTeam string
+	AwayTeam string
+}
+
+// GameLine is one player's canonical metrics for one game
+type GameLine struct {
+	Player   string
+	PlayerID int
+	TeamID   int
+	GameID   int
+	Stats    map[string]float64
+}
+
+// NFLGame is a game from the american-football api-sports API
+type NFLGame struct {
+	Game struct {
+		ID    int    `json:"id"`
+		Stage string `json:"stage"`
+		Week  string `json:"week"`
+		Date  struct {
+			Timezone  string `json:"timezone"`
+			Date      string `json:"date"`
+			Time      string `json:"time"`
+			Timestamp int64  `json:"timestamp"`
+		} `json:"date"`
+		Status struct {
+			Short string `json:"short"`
+			Long  string `json:"long"`
+		} `json:"status"`
+	} `json:"game"`
+	Teams struct {
+		Home TeamRef `json:"home"`
+		Away TeamRef `json:"away"`
+	} `json:"teams"`
+}
+
+// Statistic is a named value; api-sports sends numbers, strings or null
+type Statistic struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Float parses the value, treating null, "-" and unparsable strings as 0
+func (s Statistic) Float() float64 {
+	return statValue(s.Value)
+}
+
+func statValue(raw json.RawMessage) float64 {
+	var f float64
+	if json.Unmarshal(raw, &f) == nil {
+		return f
+	}
+	var str string
+	if json.Unmarshal(raw, &str) == nil {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+			return v
+		}
+	}
+	return 0
+}
+
+// GroupedGameStats is one team's grouped player statistics for one game, as
+// stored in team_stats.json with the game id added
+type GroupedGameStats struct {
+	Game struct {
+		ID int `json:"id"`
+	} `json:"game"`
+	Team   TeamRef `json:"team"`
+	Groups []struct {
+		Name    string `json:"name"`
+		Players []struct {
+			Player struct {
+				ID   int    `json:"id"`
+				Name string `json:"name"`
+			} `json:"player"`
+			Statistics []Statistic `json:"statistics"`
+		} `json:"players"`
+	} `json:"groups"`
+}
+
+// V1Game is a game from the v1 api-sports APIs (baseball, hockey, ...)
+type V1Game struct {
+	ID     int    `json:"id"`
+	Date   string `json:"date"`
+	Status struct {
+		Short string `json:"short"`
+		Long  string `json:"long"`
+	} `json:"status"`
+	Teams struct {
+		Home TeamRef `json:"home"`
+		Away TeamRef `json:"away"`
+	} `json:"teams"`
+}
+
+// scheduledEntry is a decoded game with the statuses that may mark it finished
+type scheduledEntry struct {
+	ScheduledGame
+	Statuses []string
+}
+
+// scheduleFormats decode /games responses by ApiSportsLayout.ScheduleFormat
+var scheduleFormats = map[string]func(data string) ([]scheduledEntry, error){
+	"nba": func(data string) ([]scheduledEntry, error) {
+		games, err := DecodeGames(data)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]scheduledEntry, len(games))
+		for i, g := range games {
+			entries[i] = scheduledEntry{
+				ScheduledGame: ScheduledGame{
+					ID:       g.ID,
+					Start:    g.Date.Start,
+					HomeTeam: g.Teams.Home.Name,
+					AwayTeam: g.Teams.Visitors.Name,
+				},
+				Statuses: []string{strconv.Itoa(g.Status.Short), g.Status.Long},
+			}
+		}
+		return entries, nil
+	},
+	"nfl": func(data string) ([]scheduledEntry, error) {
+		games, err := decodeApiSports[NFLGame](data, "games")
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]scheduledEntry, len(games))
+		for i, g := range games {
+			entries[i] = scheduledEntry{
+				ScheduledGame: ScheduledGame{
+					ID:       g.Game.ID,
+					Start:    time.Unix(g.Game.Date.Timestamp, 0).UTC().Format(time.RFC3339),
+					HomeTeam: g.Teams.Home.Name,
+					AwayTeam: g.Teams.Away.Name,
+				},
+				Statuses: []string{g.Game.Status.Short, g.Game.Status.Long},
+			}
+		}
+		return entries, nil
+	},
+	"v1": func(data string) ([]scheduledEntry, error) {
+		games, err := decodeApiSports[V1Game](data, "games")
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]scheduledEntry, len(games))
+		for i, g := range games {
+			start := g.Date
+			if t, err := time.Parse(time.RFC3339, g.Date); err == nil {
+				start = t.UTC().Format(time.RFC3339)
+			}
+			entries[i] = scheduledEntry{
+				ScheduledGame: ScheduledGame{
+					ID:       g.ID,
+					Start:    start,
+					HomeTeam: g.Teams.Home.Name,
+					AwayTeam: g.Teams.Away.Name,
+				},
+				Statuses: []string{g.Status.Short, g.Status.Long},
+			}
+		}
+		return entries, nil
+	},
+}
+
+// statsFormats decode stored player lines by ApiSportsLayout.StatsFormat
+var statsFormats = map[string]func(s *Sport, data string) ([]GameLine, error){
+	"flat":    flatGameLines,
+	"grouped": groupedGameLines,
+}
+
+// DecodeSchedule decodes a games response for sport
+func DecodeSchedule(sport string, data string) ([]ScheduledGame, error) {
+	s, err := GetSport(sport)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scheduleFormats[s.ApiSports.ScheduleFormat](data)
+	if err != nil {
+		return nil, err
+	}
+	schedule := make([]ScheduledGame, len(entries))
+	for i, e := range entries {
+		schedule[i] = e.ScheduledGame
+		for _, status := range e.Statuses {
+			if s.Finished(status) {
+				schedule[i].Finished = true
+			}
+		}
+	}
+	return schedule, nil
+}
+
+// DecodeGameLines decodes a stored team_stats.json or player_stats.json for
+// sport into canonical per-game lines
+func DecodeGameLines(sport string, data string) ([]GameLine, error) {
+	s, err := GetSport(sport)
+	if err != nil {
+		return nil, err
+	}
+	return statsFormats[s.ApiSports.StatsFormat](s, data)
+}
+
+// flatGameLines reads one entry per player per game, taking each stat field
+// as a dotted path into the entry
+func flatGameLines(s *Sport, data string) ([]GameLine, error) {
+	type flatEntry struct {
+		Player struct {
+			ID        int    `json:"id"`
+			Name      string `json:"name"`
+			Firstname string `json:"firstname"`
+			Lastname  string `json:"lastname"`
+		} `json:"player"`
+		Team TeamRef `json:"team"`
+		Game struct {
+			ID int `json:"id"`
+		} `json:"game"`
+	}
+
+	raw, err := decodeApiSports[json.RawMessage](data, "player statistics")
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]GameLine, 0, len(raw))
+	for _, r := range raw {
+		var entry flatEntry
+		var fields map[string]interface{}
+		if err := json.Unmarshal(r, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode player statistics entry: %v", err)
+		}
+		if err := json.Unmarshal(r, &fields); err != nil {
+			return nil, fmt.Errorf("failed to decode player statistics entry: %v", err)
+		}
+
+		name := entry.Player.Firstname + "_" + entry.Player.Lastname
+		if entry.Player.Firstname == "" && entry.Player.Lastname == "" {
+			name = strings.ReplaceAll(entry.Player.Name, " ", "_")
+		}
+		line := GameLine{
+			Player:   name,
+			PlayerID: entry.Player.ID,
+			TeamID:   entry.Team.ID,
+			GameID:   entry.Game.ID,
+			Stats:    zeroMetrics(s),
+		}
+		for field, metric := range s.StatFields {
+			line.Stats[metric] = fieldValue(fields, field)
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// fieldValue follows a dotted path through nested objects
+func fieldValue(fields map[string]interface{}, path string) float64 {
+	var value interface{} = fields
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return 0
+		}
+		value = obj[part]
+	}
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+// groupedGameLines flattens stat groups into one line per player per game.
+// Metrics a player has no group for are 0.
+func groupedGameLines(s *Sport, data string) ([]GameLine, error) {
+	teams, err := decodeApiSports[GroupedGameStats](data, "game player statistics")
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []GameLine
+	index := make(map[string]int)
+
+	for _, team := range teams {
+		for _, group := range team.Groups {
+			groupName := strings.ToLower(group.Name)
+			for _, p := range group.Players {
+				key := fmt.Sprintf("%d|%d", team.Game.ID, p.Player.ID)
+				i, ok := index[key]
+				if !ok {
+					lines = append(lines, GameLine{
+						Player:   strings.ReplaceAll(p.Player.Name, " ", "_"),
+						PlayerID: p.Player.ID,
+						TeamID:   team.Team.ID,
+						GameID:   team.Game.ID,
+						Stats:    zeroMetrics(s),
+					})
+					i = len(lines) - 1
+					index[key] = i
+				}
+				for _, stat := range p.Statistics {
+					if metric, ok := s.StatFields[groupName+"/"+strings.ToLower(stat.Name)]; ok {
+						lines[i].Stats[metric] = stat.Float()
+					}
+				}
+			}
+		}
+	}
+	return lines, nil
+}
+
+func zeroMetrics(s *Sport) map[string]float64 {
+	stats := make(map[string]float64, len(s.Metrics))
+	for _, metric := range s.Metrics {
+		stats[metric] = 0
+	}
+	return stats
+}
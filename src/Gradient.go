@@ -0,0 +1,202 @@
+package src
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mathext"
+)
+
+// ParamGradient returns the log likelihood of data under a distribution and
+// its derivative with respect to each parameter, in getParamKeys order
+type ParamGradient func(params map[string]float64, data []float64) (float64, []float64)
+
+var paramGradients = map[string]ParamGradient{
+	"Normal":                       normalGradient,
+	"Poisson":                      poissonGradient,
+	"Exponential":                  exponentialGradient,
+	"NegativeBinomial":             negativeBinomialGradient,
+	"Uniform":                      uniformGradient,
+	"ZeroInflatedPoisson":          zeroInflatedPoissonGradient,
+	"ZeroInflatedNegativeBinomial": zeroInflatedNegativeBinomialGradient,
+	"Binomial":                     binomialGradient,
+}
+
+// RegisterParamGradient gives a likelihood distribution an analytic
+// gradient, replacing any registered under the same name
+func RegisterParamGradient(dist string, g ParamGradient) {
+	paramGradients[dist] = g
+}
+
+// LinkJacobian is the derivative of a link's distribution parameters with
+// respect to the point: one row per parameter, one column per coordinate
+type LinkJacobian func(point []float64, data []float64) [][]float64
+
+// HasGradient reports whether Gradient is exact: the distribution has a
+// registered gradient and the link a Jacobian
+func (l *Likelihood) HasGradient() bool {
+	_, ok := paramGradients[l.DistributionParams.Dist]
+	return ok && l.LinkJacobian != nil
+}
+
+// Gradient returns CalcDataLikelihood at l.Params and its gradient with
+// respect to l.Params, by the chain rule through the link. Without an
+// analytic gradient it falls back to finite differences.
+func (l *Likelihood) Gradient() (float64, []float64) {
+	if !l.HasGradient() {
+		point := append([]float64{}, l.Params...)
+		nll := l.CalcDataLikelihood()
+		grad := gradient(func(q []float64) float64 {
+			l.Params = q
+			return l.CalcDataLikelihood()
+		}, point)
+		l.Params = point
+		return nll, grad
+	}
+
+	paramGrad := paramGradients[l.DistributionParams.Dist]
+	paramKeys := getParamKeys(l.DistributionParams.Dist)
+
+	outputdata := make([]float64, l.OutputData.Len())
+	for j := range outputdata {
+		outputdata[j] = l.OutputData.AtVec(j)
+	}
+
+	rows, cols := l.InputData.Dims()
+	nll := 0.0
+	grad := make([]float64, len(l.Params))
+	for i := 0; i < rows; i++ {
+		inputdata := make([]float64, cols)
+		for j := range inputdata {
+			inputdata[j] = l.InputData.At(i, j)
+		}
+
+		selectedParams := l.Link(l.Params, inputdata)
+		params := make(map[string]float64, len(l.DistributionParams.Params))
+		for k, v := range l.DistributionParams.Params {
+			params[k] = v
+		}
+		for k, val := range selectedParams {
+			params[paramKeys[k]] = val
+		}
+
+		ll, dParams := paramGrad(params, outputdata)
+		nll -= ll
+		jacobian := l.LinkJacobian(l.Params, inputdata)
+		// Parameters the link does not set are fixed and have no row
+		for k := range jacobian {
+			for j := range grad {
+				grad[j] -= dParams[k] * jacobian[k][j]
+			}
+		}
+	}
+	return nll, grad
+}
+
+func normalGradient(params map[string]float64, data []float64) (float64, []float64) {
+	mu, sigma := params["Mu"], params["Sigma"]
+	dMu, dSigma := 0.0, -float64(len(data))/sigma
+	for _, d := range data {
+		dMu += (d - mu) / (sigma * sigma)
+		dSigma += (d - mu) * (d - mu) / (sigma * sigma * sigma)
+	}
+	return UVNormalLogLikelihood(mu, sigma, data), []float64{dMu, dSigma}
+}
+
+func poissonGradient(params map[string]float64, data []float64) (float64, []float64) {
+	lambda := params["Lambda"]
+	dLambda := -float64(len(data))
+	for _, d := range data {
+		dLambda += d / lambda
+	}
+	return UVPoissonLogLikelihood(lambda, data), []float64{dLambda}
+}
+
+func exponentialGradient(params map[string]float64, data []float64) (float64, []float64) {
+	rate := params["Rate"]
+	return UVExponentialLogLikelihood(rate, data), []float64{float64(len(data))/rate - Sum(data)}
+}
+
+func negativeBinomialGradient(params map[string]float64, data []float64) (float64, []float64) {
+	mu, alpha := params["Mu"], params["Alpha"]
+	dMu, dAlpha := 0.0, 0.0
+	for _, d := range data {
+		dMu += d/mu - (d+alpha)/(alpha+mu)
+		dAlpha += mathext.Digamma(d+alpha) - mathext.Digamma(alpha) + math.Log(alpha/(alpha+mu)) + 1 - (d+alpha)/(alpha+mu)
+	}
+	return UVNegativeBinomialLogLikelihood(mu, alpha, data), []float64{dMu, dAlpha}
+}
+
+func zeroInflatedPoissonGradient(params map[string]float64, data []float64) (float64, []float64) {
+	lambda, pi := params["Lambda"], params["Pi"]
+	dLambda, dPi := 0.0, 0.0
+	for _, d := range data {
+		if d == 0 {
+			z := pi + (1-pi)*math.Exp(-lambda)
+			dLambda -= (1 - pi) * math.Exp(-lambda) / z
+			dPi += (1 - math.Exp(-lambda)) / z
+			continue
+		}
+		dLambda += d/lambda - 1
+		dPi -= 1 / (1 - pi)
+	}
+	return UVZeroInflatedPoissonLogLikelihood(lambda, pi, data), []float64{dLambda, dPi}
+}
+
+func zeroInflatedNegativeBinomialGradient(params map[string]float64, data []float64) (float64, []float64) {
+	mu, alpha, pi := params["Mu"], params["Alpha"], params["Pi"]
+	dMu, dAlpha, dPi := 0.0, 0.0, 0.0
+	for _, d := range data {
+		if d == 0 {
+			// f0 is the negative binomial probability of zero
+			f0 := math.Pow(alpha/(alpha+mu), alpha)
+			z := pi + (1-pi)*f0
+			dMu += (1 - pi) * f0 * (-alpha / (alpha + mu)) / z
+			dAlpha += (1 - pi) * f0 * (math.Log(alpha/(alpha+mu)) + 1 - alpha/(alpha+mu)) / z
+			dPi += (1 - f0) / z
+			continue
+		}
+		_, g := negativeBinomialGradient(params, []float64{d})
+		dMu += g[0]
+		dAlpha += g[1]
+		dPi -= 1 / (1 - pi)
+	}
+	return UVZeroInflatedNegativeBinomialLogLikelihood(mu, alpha, pi, data), []float64{dMu, dAlpha, dPi}
+}
+
+func binomialGradient(params map[string]float64, data []float64) (float64, []float64) {
+	n, p := params["N"], params["P"]
+	dP := 0.0
+	for _, d := range data {
+		dP += d/p - (n-d)/(1-p)
+	}
+	// N is a fixed count, not differentiated
+	return UVBinomialLogLikelihood(n, p, data), []float64{dP, 0}
+}
+
+func uniformGradient(params map[string]float64, data []float64) (float64, []float64) {
+	min, max := params["Min"], params["Max"]
+	n := float64(len(data))
+	return UVUniformLogLikelihood(min, max, n), []float64{n / (max - min), -n / (max - min)}
+}
+
+// DLogProb is the derivative of LogProb at x, for the priors that have an
+// analytic one
+func (d *DistributionParams) DLogProb(x float64) (float64, bool) {
+	p := d.Params
+	switch d.Dist {
+	case "Normal":
+		return -(x - p["Mu"]) / (p["Sigma"] * p["Sigma"]), true
+	case "Uniform":
+		return 0, true
+	case "Exponential":
+		return -p["Rate"], true
+	case "Gamma":
+		return (p["Alpha"]-1)/x - p["Beta"], true
+	case "Beta":
+		return (p["Alpha"]-1)/x - (p["Beta"]-1)/(1-x), true
+	case "LogNormal":
+		return -(1 + (math.Log(x)-p["Mu"])/(p["Sigma"]*p["Sigma"])) / x, true
+	default:
+		return 0, false
+	}
+}
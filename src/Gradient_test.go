@@ -0,0 +1,105 @@
+package src
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// centralDifference is the derivative of f at x by central differences
+func centralDifference(f func(float64) float64, x float64) float64 {
+	h := 1e-5 * math.Max(1, math.Abs(x))
+	return (f(x+h) - f(x-h)) / (2 * h)
+}
+
+func closeTo(got float64, want float64, tol float64) bool {
+	return math.Abs(got-want) <= tol*math.Max(1, math.Abs(want))
+}
+
+func TestLikelihoodGradientsMatchFiniteDifferences(t *testing.T) {
+	series := []float64{2, 0, 3, 1, 4, 0, 2, 5, 1, 3}
+	lags := 2
+	rows := len(series) - lags
+	inputs := mat.NewDense(rows, lags, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < lags; j++ {
+			inputs.Set(i, j, series[i+j])
+		}
+	}
+	outputs := series[lags:]
+
+	cases := []struct {
+		spec  ModelSpec
+		point []float64 // lag coefficients, intercept, then passed through parameters
+	}{
+		{ModelSpec{Likelihood: "Normal", Link: "normal"}, []float64{0.3, 0.2, 1.5}},
+		{ModelSpec{Likelihood: "Poisson", Link: "log"}, []float64{0.3, -0.2, 0.4}},
+		{ModelSpec{Likelihood: "Poisson", Link: "identity"}, []float64{0.3, 0.2, 1.5}},
+		{ModelSpec{Likelihood: "Exponential", Link: "identity"}, []float64{0.1, 0.05, 0.4}},
+		{ModelSpec{Likelihood: "NegativeBinomial", Link: "log"}, []float64{0.3, -0.2, 0.4, 2.5}},
+		{ModelSpec{Likelihood: "ZeroInflatedPoisson", Link: "log"}, []float64{0.3, -0.2, 0.4, 0.3}},
+		{ModelSpec{Likelihood: "ZeroInflatedNegativeBinomial", Link: "log"}, []float64{0.3, -0.2, 0.4, 2.5, 0.3}},
+		{ModelSpec{Likelihood: "Binomial", Link: "logit", Trials: 6}, []float64{0.3, -0.2, -0.5}},
+	}
+	for _, c := range cases {
+		c.spec.Covariates.Lags = lags
+		model, err := c.spec.Build(series)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", c.spec.Likelihood, c.spec.Link, err)
+		}
+		if len(model.Priors) != len(c.point) {
+			t.Fatalf("%s/%s: %d parameters, test point has %d", c.spec.Likelihood, c.spec.Link, len(model.Priors), len(c.point))
+		}
+		l := Likelihood{
+			Params:             append([]float64{}, c.point...),
+			DistributionParams: model.Likelihood,
+			InputData:          *inputs,
+			OutputData:         *mat.NewVecDense(len(outputs), outputs),
+			Link:               model.Link,
+			LinkJacobian:       model.LinkJacobian,
+		}
+		if !l.HasGradient() {
+			t.Fatalf("%s/%s: no analytic gradient", c.spec.Likelihood, c.spec.Link)
+		}
+
+		nll, grad := l.Gradient()
+		l.Params = append([]float64{}, c.point...)
+		if want := l.CalcDataLikelihood(); !closeTo(nll, want, 1e-9) {
+			t.Errorf("%s/%s: Gradient returned nll %v, CalcDataLikelihood %v", c.spec.Likelihood, c.spec.Link, nll, want)
+		}
+		for i := range c.point {
+			want := centralDifference(func(x float64) float64 {
+				l.Params = append([]float64{}, c.point...)
+				l.Params[i] = x
+				return l.CalcDataLikelihood()
+			}, c.point[i])
+			if !closeTo(grad[i], want, 1e-4) {
+				t.Errorf("%s/%s: d nll / d %s = %v, finite differences give %v", c.spec.Likelihood, c.spec.Link, model.ParamNames[i], grad[i], want)
+			}
+		}
+	}
+}
+
+func TestPriorDerivativesMatchFiniteDifferences(t *testing.T) {
+	cases := []struct {
+		prior DistributionParams
+		x     float64
+	}{
+		{DistributionParams{Dist: "Normal", Params: map[string]float64{"Mu": 1, "Sigma": 2}}, 0.3},
+		{DistributionParams{Dist: "Exponential", Params: map[string]float64{"Rate": 0.7}}, 1.2},
+		{DistributionParams{Dist: "Gamma", Params: map[string]float64{"Alpha": 2.5, "Beta": 1.5}}, 0.8},
+		{DistributionParams{Dist: "Beta", Params: map[string]float64{"Alpha": 2, "Beta": 3}}, 0.35},
+		{DistributionParams{Dist: "LogNormal", Params: map[string]float64{"Mu": 0.2, "Sigma": 0.5}}, 1.4},
+	}
+	for _, c := range cases {
+		got, ok := c.prior.DLogProb(c.x)
+		if !ok {
+			t.Fatalf("%s: no analytic derivative", c.prior.Dist)
+		}
+		want := centralDifference(c.prior.LogProb, c.x)
+		if !closeTo(got, want, 1e-5) {
+			t.Errorf("%s: DLogProb(%v) = %v, finite differences give %v", c.prior.Dist, c.x, got, want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned once a provider's request budget is spent
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// RateLimiter is a token bucket refilled at Rate tokens per second
+type RateLimiter struct {
+	Rate  float64
+	Burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{Rate: rate, Burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a token is available. A zero rate never blocks.
+func (r *RateLimiter) Wait() {
+	if r == nil || r.Rate <= 0 {
+		return
+	}
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		r.tokens += now.Sub(r.last).Seconds() * r.Rate
+		if r.tokens > r.Burst {
+			r.tokens = r.Burst
+		}
+		r.last = now
+		if r.tokens >= 1 {
+			r.tokens--
+			r.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - r.tokens) / r.Rate * float64(time.Second))
+		r.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// Quota tracks request usage for one provider. Remaining and Used mirror the
+// x-requests-remaining / x-requests-used headers when the provider sends them.
+type Quota struct {
+	Budget    float64
+	Spent     float64
+	Requests  int
+	Remaining float64
+	Used      float64
+	Known     bool
+}
+
+// ProviderLimits is the per-provider HTTP policy
+type ProviderLimits struct {
+	Limiter    *RateLimiter
+	Timeout    time.Duration
+	MaxRetries int
+	BaseDelay  time.Duration
+	Quota      Quota
+}
+
+var (
+	limitsMu sync.Mutex
+	limits   = map[string]*ProviderLimits{}
+)
+
+func defaultLimits() *ProviderLimits {
+	return &ProviderLimits{
+		Timeout:    30 * time.Second,
+		MaxRetries: 3,
+		BaseDelay:  500 * time.Millisecond,
+	}
+}
+
+func limitsFor(provider string) *ProviderLimits {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	l, ok := limits[provider]
+	if !ok {
+		l = defaultLimits()
+		limits[provider] = l
+	}
+	return l
+}
+
+// ConfigureLimits applies rate limit, timeout, retry and budget settings
+func ConfigureLimits(provider string, cfg ProviderConfig) {
+	l := limitsFor(provider)
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if cfg.RateLimit > 0 {
+		l.Limiter = NewRateLimiter(cfg.RateLimit, cfg.Burst)
+	}
+	if cfg.Timeout > 0 {
+		l.Timeout = time.Duration(cfg.Timeout * float64(time.Second))
+	}
+	if cfg.Retries > 0 {
+		l.MaxRetries = cfg.Retries
+	}
+	if cfg.Budget > 0 {
+		l.Quota.Budget = cfg.Budget
+	}
+}
+
+// GetQuota returns a snapshot of a provider's quota accounting
+func GetQuota(provider string) Quota {
+	l := limitsFor(provider)
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	return l.Quota
+}
+
+// QuotaReport describes a provider's quota usage for printing
+func QuotaReport(provider string) string {
+	q := GetQuota(provider)
+	report := fmt.Sprintf("%s: %d requests, %.0f quota units spent", provider, q.Requests, q.Spent)
+	if q.Budget > 0 {
+		report += fmt.Sprintf(" of %.0f budgeted", q.Budget)
+	}
+	if q.Known {
+		report += fmt.Sprintf(", %.0f remaining (%.0f used)", q.Remaining, q.Used)
+	}
+	return report
+}
+
+func (l *ProviderLimits) checkBudget() error {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if l.Quota.Budget > 0 && l.Quota.Spent >= l.Quota.Budget {
+		return fmt.Errorf("%w: spent %.0f of %.0f", ErrBudgetExhausted, l.Quota.Spent, l.Quota.Budget)
+	}
+	if l.Quota.Known && l.Quota.Remaining <= 0 {
+		return fmt.Errorf("%w: provider reports no requests remaining", ErrBudgetExhausted)
+	}
+	return nil
+}
+
+// record updates quota accounting from a response. x-requests-last is the
+// cost of the request; providers without it are charged one unit.
+func (l *ProviderLimits) record(header http.Header) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	cost := 1.0
+	if v, err := strconv.ParseFloat(header.Get("x-requests-last"), 64); err == nil {
+		cost = v
+	}
+	l.Quota.Spent += cost
+	l.Quota.Requests++
+	if v, err := strconv.ParseFloat(header.Get("x-requests-remaining"), 64); err == nil {
+		l.Quota.Remaining = v
+		l.Quota.Known = true
+	}
+	if v, err := strconv.ParseFloat(header.Get("x-requests-used"), 64); err == nil {
+		l.Quota.Used = v
+	}
+}
+
+// retryable reports whether a status code is worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// backoff returns the delay before retry attempt n, honouring Retry-After
+func (l *ProviderLimits) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return l.BaseDelay * time.Duration(1<<attempt)
+}
+
+// doRequest sends a GET through the provider's rate limiter and budget,
+// retrying transport errors, 429s and 5xx responses with exponential backoff
+func doRequest(provider string, url string, headers map[string]string) (int, string, error) {
+	l := limitsFor(provider)
+	client := &http.Client{Timeout: l.Timeout}
+
+	var lastErr error
+	for attempt := 0; attempt <= l.MaxRetries; attempt++ {
+		if err := l.checkBudget(); err != nil {
+			return 0, "", fmt.Errorf("%s: %w", provider, err)
+		}
+		l.Limiter.Wait()
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to build request: %v", err)
+		}
+		for key, value := range headers {
+			req.Header.Add(key, value)
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request failed: %v", err)
+			if attempt < l.MaxRetries {
+				time.Sleep(l.backoff(attempt, nil))
+			}
+			continue
+		}
+
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		l.record(res.Header)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read response: %v", err)
+			if attempt < l.MaxRetries {
+				time.Sleep(l.backoff(attempt, nil))
+			}
+			continue
+		}
+
+		if retryable(res.StatusCode) && attempt < l.MaxRetries {
+			lastErr = fmt.Errorf("%s returned %s", provider, res.Status)
+			delay := l.backoff(attempt, res)
+			fmt.Printf("%v, retrying in %v\n", lastErr, delay)
+			time.Sleep(delay)
+			continue
+		}
+
+		return res.StatusCode, string(body), nil
+	}
+	return 0, "", fmt.Errorf("%s: giving up after %d attempts: %v", provider, l.MaxRetries+1, lastErr)
+}
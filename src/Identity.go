@@ -0,0 +1,243 @@
+package src
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// PlayerMatchThreshold is the lowest fuzzy match confidence accepted when a
+// player has no exact or alias match
+var PlayerMatchThreshold = 0.85
+
+// distinctFirstNameSimilarity caps the score of names sharing a last name
+// whose first names differ beyond a nickname, such as "jrue holiday" and
+// "justin holiday", keeping them below PlayerMatchThreshold
+const distinctFirstNameSimilarity = 0.8
+
+// AliasFile is the per-sport alias table, stored under DataDir/<sport>
+const AliasFile = "aliases.json"
+
+// nameSuffixes are dropped from player keys
+var nameSuffixes = map[string]bool{"jr": true, "sr": true, "ii": true, "iii": true, "iv": true, "v": true}
+
+// PlayerKey normalizes a player name from any provider: underscores become
+// spaces, accents and punctuation are removed, case is folded and
+// generational suffixes are dropped. "Jaren_Jackson_Jr." and "Jaren Jackson"
+// share a key.
+func PlayerKey(name string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), name)
+	if err != nil {
+		folded = name
+	}
+	folded = strings.ToLower(strings.ReplaceAll(folded, "_", " "))
+
+	var b strings.Builder
+	for _, r := range folded {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ':
+			b.WriteRune(r)
+		case r == '-':
+			b.WriteRune(' ')
+		}
+	}
+
+	words := strings.Fields(b.String())
+	for len(words) > 1 && nameSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// PlayerAliases maps name variants, such as a sportsbook nickname, to the
+// name the stats provider uses. It is stored as a JSON object and meant to
+// be edited by hand.
+type PlayerAliases map[string]string
+
+// AliasPath returns the alias table location for sport
+func AliasPath(sport string) string {
+	return filepath.Join(DataDir, sport, AliasFile)
+}
+
+// LoadAliases reads the alias table at path; a missing file is empty. Two
+// variants that normalize to the same key must name the same player.
+func LoadAliases(path string) (PlayerAliases, error) {
+	aliases := PlayerAliases{}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return aliases, nil
+		}
+		return nil, fmt.Errorf("failed to read aliases %s: %v", path, err)
+	}
+	if err := json.Unmarshal(raw, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to parse aliases %s: %v", path, err)
+	}
+
+	seen := map[string]string{}
+	for _, variant := range SortedKeys(aliases) {
+		key := PlayerKey(variant)
+		if first, ok := seen[key]; ok && PlayerKey(aliases[first]) != PlayerKey(aliases[variant]) {
+			return nil, fmt.Errorf("aliases %s: %q and %q map %q to both %q and %q", path, first, variant, key, aliases[first], aliases[variant])
+		}
+		seen[key] = variant
+	}
+	return aliases, nil
+}
+
+// Canonical returns the key of name after applying the alias table. When
+// several variants share the key of name the first in sorted order applies.
+func (a PlayerAliases) Canonical(name string) string {
+	key := PlayerKey(name)
+	for _, variant := range SortedKeys(a) {
+		if PlayerKey(variant) == key {
+			return PlayerKey(a[variant])
+		}
+	}
+	return key
+}
+
+// PlayerMatch is how one stats player was matched to a sportsbook name
+type PlayerMatch struct {
+	Player     string  `json:"player"`
+	BookName   string  `json:"book_name,omitempty"`
+	Method     string  `json:"method"` // exact, alias, fuzzy or none
+	Confidence float64 `json:"confidence"`
+}
+
+// PlayerResolver matches stats player names to the names a sportsbook uses
+type PlayerResolver struct {
+	Aliases   PlayerAliases
+	Threshold float64
+
+	byKey map[string]string // canonical key -> book name
+	names []string
+}
+
+// NewPlayerResolver indexes the sportsbook names to match against
+func NewPlayerResolver(bookNames []string, aliases PlayerAliases, threshold float64) *PlayerResolver {
+	r := &PlayerResolver{Aliases: aliases, Threshold: threshold, byKey: map[string]string{}}
+	for _, name := range bookNames {
+		key := aliases.Canonical(name)
+		if _, ok := r.byKey[key]; ok {
+			continue
+		}
+		r.byKey[key] = name
+		r.names = append(r.names, name)
+	}
+	sort.Strings(r.names)
+	return r
+}
+
+// Resolve finds the sportsbook name for a stats player. Below the threshold,
+// or when two names score the same, the best candidate is still reported
+// but Method is none.
+func (r *PlayerResolver) Resolve(player string) PlayerMatch {
+	match := PlayerMatch{Player: player, Method: "none"}
+
+	if name, ok := r.byKey[PlayerKey(player)]; ok {
+		match.BookName, match.Method, match.Confidence = name, "exact", 1
+		return match
+	}
+	key := r.Aliases.Canonical(player)
+	if name, ok := r.byKey[key]; ok {
+		match.BookName, match.Method, match.Confidence = name, "alias", 1
+		return match
+	}
+
+	tied := false
+	for _, name := range r.names {
+		score := NameSimilarity(key, r.Aliases.Canonical(name))
+		switch {
+		case score > match.Confidence:
+			match.BookName, match.Confidence, tied = name, score, false
+		case score == match.Confidence && score > 0:
+			tied = true
+		}
+	}
+	if match.Confidence >= r.Threshold && !tied {
+		match.Method = "fuzzy"
+	}
+	return match
+}
+
+// NameSimilarity scores two player keys between 0 and 1. Edit distance is
+// used throughout; with a shared last name, a first name that is a prefix of
+// the other ("nic claxton" / "nicolas claxton") scores at least 0.95, and
+// any other first name at most distinctFirstNameSimilarity, so relatives and
+// namesakes ("jalen williams" / "jaylin williams") need an alias to match.
+func NameSimilarity(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if a == "" || b == "" {
+		return 0
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	score := 1 - float64(levenshtein(ra, rb))/float64(longest)
+
+	wa, wb := strings.Fields(a), strings.Fields(b)
+	if len(wa) > 1 && len(wb) > 1 && wa[len(wa)-1] == wb[len(wb)-1] {
+		fa, fb := wa[0], wb[0]
+		if strings.HasPrefix(fa, fb) || strings.HasPrefix(fb, fa) {
+			score = max(score, 0.95)
+		} else {
+			score = min(score, distinctFirstNameSimilarity)
+		}
+	}
+	return score
+}
+
+func levenshtein(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// MatchReport lists how an arbitrage run matched players
+type MatchReport struct {
+	Fuzzy                []PlayerMatch `json:"fuzzy"`
+	UnmatchedPredictions []PlayerMatch `json:"unmatched_predictions"`
+	UnmatchedOdds        []string      `json:"unmatched_odds"`
+}
+
+// Print writes a short summary of the report
+func (m MatchReport) Print() {
+	for _, f := range m.Fuzzy {
+		fmt.Printf("Fuzzy matched %s to %q (confidence %.2f)\n", f.Player, f.BookName, f.Confidence)
+	}
+	for _, u := range m.UnmatchedPredictions {
+		if u.BookName != "" {
+			fmt.Printf("Unmatched player %s (closest %q, confidence %.2f)\n", u.Player, u.BookName, u.Confidence)
+		} else {
+			fmt.Printf("Unmatched player %s\n", u.Player)
+		}
+	}
+	fmt.Printf("%d fuzzy matches, %d players without odds, %d sportsbook players without predictions\n",
+		len(m.Fuzzy), len(m.UnmatchedPredictions), len(m.UnmatchedOdds))
+}
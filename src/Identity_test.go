@@ -0,0 +1,117 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlayerKeyFoldsProviderSpellings(t *testing.T) {
+	cases := map[string]string{
+		"Jaren_Jackson_Jr.":   "jaren jackson",
+		"Robert Williams III": "robert williams",
+		"Nikola Jokić":        "nikola jokic",
+		"Luka Dončić":         "luka doncic",
+		"Karl-Anthony Towns":  "karl anthony towns",
+		"D'Angelo Russell":    "dangelo russell",
+		"  Kelly  Oubre Jr ":  "kelly oubre",
+		"V":                   "v",
+	}
+	for name, want := range cases {
+		if got := PlayerKey(name); got != want {
+			t.Errorf("PlayerKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNameSimilarityFirstNames(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		match bool
+	}{
+		{"nic claxton", "nicolas claxton", true},
+		{"herb jones", "herbert jones", true},
+		{"jrue holiday", "justin holiday", false},
+		{"jalen williams", "jaylin williams", false},
+		{"aaron holiday", "justin holiday", false},
+	}
+	for _, c := range cases {
+		score := NameSimilarity(c.a, c.b)
+		if score != NameSimilarity(c.b, c.a) {
+			t.Errorf("NameSimilarity(%q, %q) is not symmetric", c.a, c.b)
+		}
+		if c.match && score < 0.95 {
+			t.Errorf("NameSimilarity(%q, %q) = %.3f, want a nickname match of at least 0.95", c.a, c.b, score)
+		}
+		if !c.match && score > distinctFirstNameSimilarity {
+			t.Errorf("NameSimilarity(%q, %q) = %.3f, want namesakes capped at %.2f", c.a, c.b, score, distinctFirstNameSimilarity)
+		}
+	}
+}
+
+func TestResolvePlayers(t *testing.T) {
+	book := []string{"Nicolas Claxton", "Jrue Holiday", "Justin Holiday", "Jalen Williams", "Jaylin Williams", "Herbert Jones"}
+	aliases := PlayerAliases{"Herb Jones": "Herbert Jones"}
+	r := NewPlayerResolver(book, aliases, PlayerMatchThreshold)
+
+	cases := []struct {
+		player string
+		book   string
+		method string
+	}{
+		{"Justin_Holiday", "Justin Holiday", "exact"},
+		{"Herb Jones", "Herbert Jones", "alias"},
+		{"Nic Claxton", "Nicolas Claxton", "fuzzy"},
+		// A namesake is reported as the closest candidate without matching
+		{"Aaron Holiday", "", "none"},
+		// "J" prefixes both Jalen and Jaylin: a tie matches neither
+		{"J Williams", "", "none"},
+	}
+	for _, c := range cases {
+		m := r.Resolve(c.player)
+		if m.Method != c.method {
+			t.Errorf("Resolve(%q) method = %s (%q, %.3f), want %s", c.player, m.Method, m.BookName, m.Confidence, c.method)
+		}
+		if c.book != "" && m.BookName != c.book {
+			t.Errorf("Resolve(%q) = %q, want %q", c.player, m.BookName, c.book)
+		}
+	}
+
+	if m := r.Resolve("Aaron Holiday"); m.Confidence >= PlayerMatchThreshold {
+		t.Errorf("namesake confidence %.3f reaches the threshold %.2f", m.Confidence, PlayerMatchThreshold)
+	}
+	if m := r.Resolve("J Williams"); m.Confidence < PlayerMatchThreshold {
+		t.Errorf("tied candidates scored %.3f, want them above the threshold so only the tie rejects them", m.Confidence)
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	aliases, err := LoadAliases(filepath.Join(dir, "missing.json"))
+	if err != nil || len(aliases) != 0 {
+		t.Errorf("missing file gave %v, %v; want an empty table", aliases, err)
+	}
+
+	// Spellings of one variant may repeat as long as they agree
+	path := write("same.json", `{"Nic Claxton": "Nicolas Claxton", "nic_claxton": "Nicolas_Claxton"}`)
+	aliases, err = LoadAliases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := aliases.Canonical("NIC CLAXTON"); got != "nicolas claxton" {
+		t.Errorf("Canonical = %q, want nicolas claxton", got)
+	}
+
+	path = write("conflict.json", `{"Nic Claxton": "Nicolas Claxton", "nic claxton": "Nick Claxton"}`)
+	if _, err := LoadAliases(path); err == nil {
+		t.Error("variants of one key naming different players were accepted")
+	}
+}
@@ -0,0 +1,170 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// LoadRawResponse reads a saved api-sports response file. A missing file
+// returns nil without error.
+func LoadRawResponse(path string) (map[string]interface{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return data, nil
+}
+
+// GameEntryKey identifies an entry of a games response. NFL games nest the
+// id under "game".
+func GameEntryKey(entry map[string]interface{}) string {
+	if g, ok := entry["game"].(map[string]interface{}); ok {
+		return fmt.Sprintf("%v", g["id"])
+	}
+	return fmt.Sprintf("%v", entry["id"])
+}
+
+// StatEntryKey identifies an entry of a player statistics response by game
+// and player, or by game and team for responses grouped per team
+func StatEntryKey(entry map[string]interface{}) string {
+	var game, owner interface{}
+	if g, ok := entry["game"].(map[string]interface{}); ok {
+		game = g["id"]
+	}
+	if p, ok := entry["player"].(map[string]interface{}); ok {
+		owner = p["id"]
+	} else if t, ok := entry["team"].(map[string]interface{}); ok {
+		owner = fmt.Sprintf("team %v", t["id"])
+	}
+	return fmt.Sprintf("%v|%v", game, owner)
+}
+
+// MergeResponses appends the response entries of incoming to existing,
+// dropping any whose key is already present, and updates the results count.
+// Either side may be nil.
+func MergeResponses(existing map[string]interface{}, incoming map[string]interface{}, key func(map[string]interface{}) string) map[string]interface{} {
+	if existing == nil {
+		existing = map[string]interface{}{}
+	}
+
+	seen := make(map[string]bool)
+	merged := make([]interface{}, 0)
+	for _, source := range []map[string]interface{}{existing, incoming} {
+		if source == nil {
+			continue
+		}
+		entries, _ := source["response"].([]interface{})
+		for _, e := range entries {
+			entry, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			k := key(entry)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			merged = append(merged, entry)
+		}
+	}
+
+	existing["response"] = merged
+	existing["results"] = len(merged)
+	return existing
+}
+
+// UpsertResponses is MergeResponses, except that an incoming entry replaces
+// the existing entry with the same key in place, so refreshed games pick up
+// their new status
+func UpsertResponses(existing map[string]interface{}, incoming map[string]interface{}, key func(map[string]interface{}) string) map[string]interface{} {
+	merged := MergeResponses(existing, nil, key)
+	entries := merged["response"].([]interface{})
+
+	index := make(map[string]int, len(entries))
+	for i, e := range entries {
+		index[key(e.(map[string]interface{}))] = i
+	}
+	if incoming != nil {
+		incomingEntries, _ := incoming["response"].([]interface{})
+		for _, e := range incomingEntries {
+			entry, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			k := key(entry)
+			if i, ok := index[k]; ok {
+				entries[i] = entry
+				continue
+			}
+			index[k] = len(entries)
+			entries = append(entries, entry)
+		}
+	}
+
+	merged["response"] = entries
+	merged["results"] = len(entries)
+	return merged
+}
+
+// NewFinishedGames returns finished games from the schedule that are newer
+// than the latest game already present in stats, oldest first
+func NewFinishedGames(schedule []ScheduledGame, stats []GameLine) []ScheduledGame {
+	stored := storedGameIDs(stats)
+	latest := LatestStoredGame(schedule, stats)
+
+	var games []ScheduledGame
+	for _, g := range schedule {
+		if stored[g.ID] || !g.Finished {
+			continue
+		}
+		if latest != "" && g.Start <= latest {
+			continue
+		}
+		games = append(games, g)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Start < games[j].Start
+	})
+	return games
+}
+
+// LatestStoredGame returns the start time of the most recent scheduled game
+// that already has stats, or "" when none do
+func LatestStoredGame(schedule []ScheduledGame, stats []GameLine) string {
+	stored := storedGameIDs(stats)
+	latest := ""
+	for _, g := range schedule {
+		if stored[g.ID] && g.Start > latest {
+			latest = g.Start
+		}
+	}
+	return latest
+}
+
+func storedGameIDs(stats []GameLine) map[int]bool {
+	stored := make(map[int]bool)
+	for _, s := range stats {
+		stored[s.GameID] = true
+	}
+	return stored
+}
+
+// Finished reports whether a game has a final score
+func (g Game) Finished() bool {
+	return g.Status.Short == 3 || g.Status.Long == "Finished"
+}
+
+// SaveRawResponse writes a merged response back next to the original file
+func SaveRawResponse(data map[string]interface{}, path string) error {
+	return SaveToFile(data, filepath.Dir(path), filepath.Base(path))
+}
@@ -0,0 +1,480 @@
+package src
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONStore keeps pipeline state as the nested JSON tree under Root:
+//
+//	<sport>/<season>/<team or player>/{games,team_stats,player_stats}.json
+//	<sport>/<season>/<team or player>/preds/<player>_preds.json
+//	<sport>/<year>/<date>/<event>/odds.json and history/
+//	<sport>/<year>/<date>/arbitrage.json
+//	bets.json
+//
+// Every output has its manifest next to it, e.g. bets.manifest.json.
+type JSONStore struct {
+	Root string
+
+	mu     sync.Mutex
+	events map[string]map[string]EventKey // FindEvent index per sport
+}
+
+func (j *JSONStore) SaveDocument(key DataKey, name string, raw string) error {
+	parsed := ParseData(raw)
+	if parsed == nil {
+		return fmt.Errorf("error parsing %s", name)
+	}
+	return SaveToFile(parsed, key.Dir(j.Root), name)
+}
+
+func (j *JSONStore) SaveGames(key DataKey, raw string) error {
+	return j.mergeResponse(filepath.Join(key.Dir(j.Root), "games.json"), raw, GameEntryKey)
+}
+
+func (j *JSONStore) LoadSchedule(key DataKey) ([]ScheduledGame, error) {
+	raw, err := os.ReadFile(filepath.Join(key.Dir(j.Root), "games.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read schedule of %s: %v", key, err)
+	}
+	return DecodeSchedule(key.Sport, string(raw))
+}
+
+func (j *JSONStore) SeasonSchedule(sport string, season string) ([]ScheduledGame, error) {
+	paths, err := filepath.Glob(filepath.Join(j.Root, sport, season, "*", "games.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %v", err)
+	}
+	var games []ScheduledGame
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		schedule, err := DecodeSchedule(sport, string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		games = append(games, schedule...)
+	}
+	return games, nil
+}
+
+func (j *JSONStore) SaveGameStats(key DataKey, raw string) error {
+	return j.mergeResponse(filepath.Join(key.Dir(j.Root), statsFile(key)), raw, StatEntryKey)
+}
+
+// statsFile names the stats file of a team or player directory
+func statsFile(key DataKey) string {
+	if key.Kind == "player" {
+		return "player_stats.json"
+	}
+	return "team_stats.json"
+}
+
+// mergeResponse upserts a response's entries into the file at path
+func (j *JSONStore) mergeResponse(path string, raw string, key func(map[string]interface{}) string) error {
+	incoming := ParseData(raw)
+	if incoming == nil {
+		return fmt.Errorf("error parsing response for %s", path)
+	}
+	stored, err := LoadRawResponse(path)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return SaveRawResponse(incoming, path)
+	}
+	return SaveRawResponse(UpsertResponses(stored, incoming, key), path)
+}
+
+func (j *JSONStore) LoadGameLines(key DataKey) ([]GameLine, error) {
+	raw, err := os.ReadFile(filepath.Join(key.Dir(j.Root), statsFile(key)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read stats of %s: %v", key, err)
+	}
+	return DecodeGameLines(key.Sport, string(raw))
+}
+
+func (j *JSONStore) HasGameLines(key DataKey) (bool, error) {
+	return fileExists(filepath.Join(key.Dir(j.Root), statsFile(key))), nil
+}
+
+func (j *JSONStore) StatKeys() ([]DataKey, error) {
+	var keys []DataKey
+	for _, kind := range []string{"team", "player"} {
+		key := DataKey{Kind: kind}
+		paths, err := filepath.Glob(filepath.Join(j.Root, "*", "*", "*", statsFile(key)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stats: %v", err)
+		}
+		for _, path := range paths {
+			parts := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+			n := len(parts)
+			keys = append(keys, DataKey{Sport: parts[n-3], Season: parts[n-2], Name: parts[n-1], Kind: kind})
+		}
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a].String() < keys[b].String() })
+	return keys, nil
+}
+
+func (j *JSONStore) SaveOdds(key EventKey, label string, timestamp time.Time, raw string, primary bool) error {
+	parsed := ParseData(raw)
+	if parsed == nil {
+		return fmt.Errorf("error parsing odds of %s", key.Event)
+	}
+	dir := key.Dir(j.Root)
+	j.mu.Lock()
+	j.events = nil
+	j.mu.Unlock()
+	if primary {
+		if err := SaveToFile(parsed, dir, "odds.json"); err != nil {
+			return fmt.Errorf("error saving odds data: %v", err)
+		}
+	}
+	if err := SaveSnapshot(parsed, dir, label, timestamp); err != nil {
+		return fmt.Errorf("error saving %s snapshot: %v", label, err)
+	}
+	return nil
+}
+
+func (j *JSONStore) HasOdds(key EventKey, label string) (bool, error) {
+	if label == "" {
+		return fileExists(filepath.Join(key.Dir(j.Root), "odds.json")), nil
+	}
+	return HasSnapshot(key.Dir(j.Root), label), nil
+}
+
+func (j *JSONStore) Odds(scope OddsScope) (map[EventKey]EventOdds, error) {
+	root := filepath.Join(j.Root, scope.Sport, scope.Season)
+	if scope.Season != "" && len(scope.Date) == len("2006-01-02") {
+		root = filepath.Join(root, scope.Date)
+	}
+
+	// Older runs left event directories with spaces in their names
+	if err := RenameDirsInDir(root); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to rename directories: %w", err)
+	}
+
+	data := make(map[EventKey]EventOdds)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path == root {
+				return filepath.SkipDir
+			}
+			return fmt.Errorf("error accessing path %s: %w", path, err)
+		}
+		if info.IsDir() || info.Name() != "odds.json" {
+			return nil
+		}
+		key, ok := j.eventKey(path)
+		if !ok || key.Sport != scope.Sport || !scope.matches(key.Date) {
+			return nil
+		}
+
+		fmt.Println("Reading JSON file:", path)
+		fileData, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		snapshot, err := DecodeEventOdds(string(fileData))
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", path, err)
+			return nil
+		}
+		data[key] = snapshot.Data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process directory: %w", err)
+	}
+	return data, nil
+}
+
+// eventKey reads the key of an odds.json at <root>/<sport>/<year>/<date>/<event>/odds.json
+func (j *JSONStore) eventKey(path string) (EventKey, bool) {
+	rel, err := filepath.Rel(j.Root, filepath.Dir(path))
+	if err != nil {
+		return EventKey{}, false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) != 4 {
+		return EventKey{}, false
+	}
+	return EventKey{Sport: parts[0], Date: parts[2], Event: parts[3]}, true
+}
+
+func (j *JSONStore) OddsAt(key EventKey, target time.Time) (OddsSnapshot, bool, error) {
+	file, ok, err := SnapshotAt(key.Dir(j.Root), target)
+	if err != nil || !ok {
+		return OddsSnapshot{}, false, err
+	}
+	fileData, err := os.ReadFile(file.Path)
+	if err != nil {
+		return OddsSnapshot{}, false, fmt.Errorf("failed to read file %s: %w", file.Path, err)
+	}
+	decoded, err := DecodeEventOdds(string(fileData))
+	if err != nil {
+		fmt.Printf("Skipping %s: %v\n", file.Path, err)
+		return OddsSnapshot{}, false, nil
+	}
+	return OddsSnapshot{Key: key, Label: file.Label, Timestamp: file.Timestamp, Event: decoded.Data}, true, nil
+}
+
+func (j *JSONStore) FindEvent(sport string, homeTeam string, awayTeam string, commenceTime string) (EventKey, bool, error) {
+	sports := []string{sport}
+	if sport == "" {
+		sports = SportNames()
+	}
+	for _, s := range sports {
+		events, err := j.eventIndex(s)
+		if err != nil {
+			return EventKey{}, false, err
+		}
+		if key, ok := events[eventKey(homeTeam, awayTeam, commenceTime)]; ok {
+			return key, true, nil
+		}
+	}
+	return EventKey{}, false, nil
+}
+
+// eventIndex maps the teams and commence time of every stored event to its
+// key, walking the sport's odds once per store
+func (j *JSONStore) eventIndex(sport string) (map[string]EventKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if index, ok := j.events[sport]; ok {
+		return index, nil
+	}
+	if j.events == nil {
+		j.events = make(map[string]map[string]EventKey)
+	}
+
+	index := make(map[string]EventKey)
+	root := filepath.Join(j.Root, sport)
+	if !fileExists(root) {
+		j.events[sport] = index
+		return index, nil
+	}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != "odds.json" {
+			return nil
+		}
+		key, ok := j.eventKey(path)
+		if !ok {
+			return nil
+		}
+		fileData, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		snapshot, err := DecodeEventOdds(string(fileData))
+		if err != nil {
+			return nil
+		}
+		event := snapshot.Data
+		index[eventKey(event.HomeTeam, event.AwayTeam, event.CommenceTime)] = key
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index odds: %w", err)
+	}
+	j.events[sport] = index
+	return index, nil
+}
+
+func (j *JSONStore) PropOdds(q PropQuery) ([]PropLine, error) {
+	sports := []string{q.Sport}
+	if q.Sport == "" {
+		sports = SportNames()
+	}
+
+	var lines []PropLine
+	for _, sport := range sports {
+		root := filepath.Join(j.Root, sport)
+		if !fileExists(root) {
+			continue
+		}
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".json") || IsManifest(path) {
+				return nil
+			}
+
+			dir, label := filepath.Dir(path), "day"
+			if filepath.Base(dir) == HistoryDir {
+				if !q.AllSnapshots {
+					return nil
+				}
+				dir = filepath.Dir(dir)
+				_, label, _ = strings.Cut(strings.TrimSuffix(info.Name(), ".json"), "_")
+			} else if info.Name() != "odds.json" {
+				return nil
+			} else if q.AllSnapshots {
+				// odds.json duplicates the day snapshot in history
+				return nil
+			}
+			key, ok := j.eventKey(filepath.Join(dir, "odds.json"))
+			if !ok || !q.matchesDate(key.Date) {
+				return nil
+			}
+
+			fileData, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read file %s: %w", path, err)
+			}
+			snapshot, err := DecodeEventOdds(string(fileData))
+			if err != nil {
+				return nil
+			}
+			lines = append(lines, q.filter(snapshot, label)...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search odds: %w", err)
+		}
+	}
+	sortPropLines(lines)
+	return lines, nil
+}
+
+func (j *JSONStore) SavePredictions(key DataKey, player string, preds map[string][]float64) error {
+	return SaveToFile(map[string]map[string][]float64{player: preds}, filepath.Join(key.Dir(j.Root), "preds"), player+"_preds.json")
+}
+
+func (j *JSONStore) LoadPredictions(key DataKey) (map[string]map[string][]float64, error) {
+	dir := filepath.Join(key.Dir(j.Root), "preds")
+	if !fileExists(dir) {
+		return nil, fmt.Errorf("no predictions stored for %s", key)
+	}
+	return ReadPreds(dir), nil
+}
+
+func (j *JSONStore) SaveArbitrage(scope OddsScope, results []ArbitrageResult) error {
+	return SaveResultsToFile(results, scope.Dir(j.Root), "arbitrage.json")
+}
+
+func (j *JSONStore) LoadArbitrage() (map[string][]ArbitrageResult, error) {
+	arbs := make(map[string][]ArbitrageResult)
+	paths, err := filepath.Glob(filepath.Join(j.Root, "*", "*", "*", "arbitrage.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list arbitrage results: %v", err)
+	}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		var results []ArbitrageResult
+		if err := json.Unmarshal(raw, &results); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		rel, _ := filepath.Rel(j.Root, filepath.Dir(path))
+		arbs[filepath.ToSlash(rel)] = results
+	}
+	return arbs, nil
+}
+
+func (j *JSONStore) SaveBets(bets []BetSlip) error {
+	return SaveToFile(bets, j.Root, "bets.json")
+}
+
+func (j *JSONStore) LoadBets() ([]BetSlip, error) {
+	return ReadBets(filepath.Join(j.Root, "bets.json"))
+}
+
+func (j *JSONStore) SaveManifest(m Manifest) error {
+	return SaveFileManifest(filepath.Join(j.Root, filepath.FromSlash(m.Output)), m)
+}
+
+func (j *JSONStore) LoadManifest(ref string) (Manifest, bool, error) {
+	return LoadFileManifest(filepath.Join(j.Root, filepath.FromSlash(ref)))
+}
+
+func (j *JSONStore) Close() error {
+	return nil
+}
+
+// ReadBets reads a bets.json file
+func ReadBets(path string) ([]BetSlip, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	var slips []BetSlip
+	if err := json.Unmarshal(raw, &slips); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return slips, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (q PropQuery) matchesDate(date string) bool {
+	return (q.From == "" || date >= q.From) && (q.To == "" || date <= q.To)
+}
+
+// filter returns the player props of a snapshot matching the query
+func (q PropQuery) filter(snapshot HistoricalSnapshot[EventOdds], label string) []PropLine {
+	player := PlayerKey(q.Player)
+	var lines []PropLine
+	for market, outcomes := range FlattenOutcomes(snapshot.Data) {
+		if q.Market != "" && market != q.Market {
+			continue
+		}
+		for _, outcome := range outcomes {
+			if outcome.Description == "" {
+				continue
+			}
+			if q.Player != "" && PlayerKey(outcome.Description) != player {
+				continue
+			}
+			if q.Bookmaker != "" && outcome.Bookmaker != q.Bookmaker {
+				continue
+			}
+			lines = append(lines, PropLine{BetOutcome: outcome, Snapshot: snapshot.Timestamp, Label: label})
+		}
+	}
+	return lines
+}
+
+func sortPropLines(lines []PropLine) {
+	sort.SliceStable(lines, func(a, b int) bool {
+		x, y := lines[a], lines[b]
+		if x.Time != y.Time {
+			return x.Time < y.Time
+		}
+		if x.Snapshot != y.Snapshot {
+			return x.Snapshot < y.Snapshot
+		}
+		if x.Market != y.Market {
+			return x.Market < y.Market
+		}
+		if x.Bookmaker != y.Bookmaker {
+			return x.Bookmaker < y.Bookmaker
+		}
+		return x.Name < y.Name
+	})
+}
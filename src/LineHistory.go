@@ -0,0 +1,144 @@
+package src
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryDir is the directory next to odds.json holding timestamped snapshots
+const HistoryDir = "history"
+
+const snapshotTimeFormat = "20060102T150405Z"
+
+// SnapshotSpec names a point in time relative to an event's commence time.
+// "close" is the line at tip-off, "day" is midnight UTC on the game date
+// (what a plain fetchodds stores in odds.json) and a duration such as "6h"
+// is that long before tip-off.
+type SnapshotSpec struct {
+	Label  string
+	Offset time.Duration
+	Day    bool
+}
+
+// ParseSnapshotSpecs parses a comma separated list such as "24h,6h,1h,close"
+func ParseSnapshotSpecs(list string) ([]SnapshotSpec, error) {
+	var specs []SnapshotSpec
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		spec, err := ParseSnapshotSpec(item)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func ParseSnapshotSpec(item string) (SnapshotSpec, error) {
+	switch item {
+	case "close":
+		return SnapshotSpec{Label: "close"}, nil
+	case "day":
+		return SnapshotSpec{Label: "day", Day: true}, nil
+	}
+	offset, err := time.ParseDuration(item)
+	if err != nil || offset < 0 {
+		return SnapshotSpec{}, fmt.Errorf("invalid snapshot %q: use close, day or a duration before tip-off like 6h", item)
+	}
+	return SnapshotSpec{Label: "T-" + item, Offset: offset}, nil
+}
+
+// Time returns when the snapshot should be taken for an event
+func (s SnapshotSpec) Time(commence time.Time) time.Time {
+	commence = commence.UTC()
+	if s.Day {
+		return time.Date(commence.Year(), commence.Month(), commence.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return commence.Add(-s.Offset)
+}
+
+// SnapshotFile is one stored odds snapshot of an event
+type SnapshotFile struct {
+	Timestamp time.Time
+	Label     string
+	Path      string
+}
+
+// SaveSnapshot stores a snapshot under dir/history, named by the timestamp
+// the provider reports for it and the label it was requested under
+func SaveSnapshot(data interface{}, dir string, label string, timestamp time.Time) error {
+	name := timestamp.UTC().Format(snapshotTimeFormat) + "_" + label + ".json"
+	return SaveToFile(data, filepath.Join(dir, HistoryDir), name)
+}
+
+// HasSnapshot reports whether a snapshot with the given label is stored
+func HasSnapshot(dir string, label string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, HistoryDir, "*_"+label+".json"))
+	return len(matches) > 0
+}
+
+// ListSnapshots returns the stored snapshots of an event, oldest first
+func ListSnapshots(dir string) ([]SnapshotFile, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, HistoryDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot history: %v", err)
+	}
+
+	var snapshots []SnapshotFile
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		stamp, label, ok := strings.Cut(name, "_")
+		if entry.IsDir() || !ok || IsManifest(entry.Name()) {
+			continue
+		}
+		ts, err := time.Parse(snapshotTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, SnapshotFile{
+			Timestamp: ts,
+			Label:     label,
+			Path:      filepath.Join(dir, HistoryDir, entry.Name()),
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+	return snapshots, nil
+}
+
+// SnapshotAt returns the latest stored snapshot taken at or before target
+func SnapshotAt(dir string, target time.Time) (SnapshotFile, bool, error) {
+	snapshots, err := ListSnapshots(dir)
+	if err != nil {
+		return SnapshotFile{}, false, err
+	}
+	var found SnapshotFile
+	ok := false
+	for _, s := range snapshots {
+		if s.Timestamp.After(target) {
+			break
+		}
+		found, ok = s, true
+	}
+	return found, ok, nil
+}
+
+// ParseTime parses the RFC3339 timestamps both providers use
+func ParseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %v", value, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,162 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LinearPredictor is the dot product of data with the leading coordinates
+// of point plus the last coordinate as intercept
+func LinearPredictor(point []float64, data []float64) float64 {
+	eta := 0.0
+	for i, val := range data {
+		eta += val * point[i]
+	}
+	return eta + point[len(point)-1]
+}
+
+// LinearPredictorGradient is the derivative of LinearPredictor with respect
+// to point
+func LinearPredictorGradient(point []float64, data []float64) []float64 {
+	grad := make([]float64, len(point))
+	copy(grad, data)
+	grad[len(point)-1] = 1
+	return grad
+}
+
+// LinkBuilder makes a link and its Jacobian for a number of covariates.
+// The linear predictor sets the likelihood's first Params parameters; the
+// coordinates of the point after the intercept are passed through as the
+// rest, such as a dispersion or zero-inflation.
+type LinkBuilder struct {
+	Params int
+	Build  func(covariates int) (func([]float64, []float64) []float64, LinkJacobian)
+}
+
+var links = map[string]LinkBuilder{
+	"normal":   {Params: 2, Build: NormalLink},
+	"identity": {Params: 1, Build: IdentityLink},
+	"log":      {Params: 1, Build: LogLink},
+	"logit":    {Params: 1, Build: LogitLink},
+}
+
+// RegisterLink makes a link available to model specs by name, replacing any
+// registered under the same name
+func RegisterLink(name string, l LinkBuilder) {
+	links[name] = l
+}
+
+// GetLink returns the link registered under name
+func GetLink(name string) (LinkBuilder, error) {
+	l, ok := links[name]
+	if !ok {
+		names := make([]string, 0, len(links))
+		for n := range links {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return LinkBuilder{}, fmt.Errorf("unknown link %q (registered: %s)", name, strings.Join(names, ", "))
+	}
+	return l, nil
+}
+
+// NormalLink returns the link bayes uses for Normal likelihoods: with the
+// linear predictor l = b + sum of c_i x_i, Mu = max(l, 0) and Sigma = |l|
+func NormalLink(covariates int) (func([]float64, []float64) []float64, LinkJacobian) {
+	link := func(point []float64, data []float64) []float64 {
+		lambda := LinearPredictor(point[:covariates+1], data)
+		return []float64{math.Max(lambda, 0), math.Abs(lambda)}
+	}
+	jacobian := func(point []float64, data []float64) [][]float64 {
+		lambda := LinearPredictor(point[:covariates+1], data)
+		dMu := make([]float64, len(point))
+		dSigma := make([]float64, len(point))
+		for i, g := range LinearPredictorGradient(point[:covariates+1], data) {
+			if lambda > 0 {
+				dMu[i] = g
+			}
+			dSigma[i] = math.Copysign(g, lambda)
+		}
+		return [][]float64{dMu, dSigma}
+	}
+	return link, jacobian
+}
+
+// IdentityLink returns a link whose first parameter is the linear predictor
+// b + sum of c_i x_i itself
+func IdentityLink(covariates int) (func([]float64, []float64) []float64, LinkJacobian) {
+	link := func(point []float64, data []float64) []float64 {
+		params := []float64{LinearPredictor(point[:covariates+1], data)}
+		return append(params, point[covariates+1:]...)
+	}
+	jacobian := func(point []float64, data []float64) [][]float64 {
+		return passThroughJacobian(point, covariates, LinearPredictorGradient(point[:covariates+1], data))
+	}
+	return link, jacobian
+}
+
+// LogLink returns a link for count likelihoods whose first parameter is a
+// mean: mean = exp(b + sum of c_i log(1 + x_i)) for the lag features x_i,
+// with the coefficients c_i and intercept b in the first covariates+1
+// coordinates of the point
+func LogLink(covariates int) (func([]float64, []float64) []float64, LinkJacobian) {
+	link := func(point []float64, data []float64) []float64 {
+		params := []float64{math.Exp(LinearPredictor(point[:covariates+1], log1pAll(data)))}
+		return append(params, point[covariates+1:]...)
+	}
+	jacobian := func(point []float64, data []float64) [][]float64 {
+		features := log1pAll(data)
+		mean := math.Exp(LinearPredictor(point[:covariates+1], features))
+		grad := LinearPredictorGradient(point[:covariates+1], features)
+		for i := range grad {
+			grad[i] *= mean
+		}
+		return passThroughJacobian(point, covariates, grad)
+	}
+	return link, jacobian
+}
+
+// LogitLink returns a link for likelihoods whose first parameter is a
+// probability, such as a Binomial's P: P = sigmoid(b + sum of c_i log(1 + x_i))
+// with the same features and coordinates as LogLink
+func LogitLink(covariates int) (func([]float64, []float64) []float64, LinkJacobian) {
+	link := func(point []float64, data []float64) []float64 {
+		params := []float64{sigmoid(LinearPredictor(point[:covariates+1], log1pAll(data)))}
+		return append(params, point[covariates+1:]...)
+	}
+	jacobian := func(point []float64, data []float64) [][]float64 {
+		features := log1pAll(data)
+		p := sigmoid(LinearPredictor(point[:covariates+1], features))
+		grad := LinearPredictorGradient(point[:covariates+1], features)
+		for i := range grad {
+			grad[i] *= p * (1 - p)
+		}
+		return passThroughJacobian(point, covariates, grad)
+	}
+	return link, jacobian
+}
+
+// passThroughJacobian is the Jacobian of a link whose first parameter has
+// gradient first and whose further parameters are the coordinates after the
+// intercept
+func passThroughJacobian(point []float64, covariates int, first []float64) [][]float64 {
+	rows := make([][]float64, 1, len(point)-covariates)
+	rows[0] = make([]float64, len(point))
+	copy(rows[0], first)
+	for j := covariates + 1; j < len(point); j++ {
+		row := make([]float64, len(point))
+		row[j] = 1
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func log1pAll(data []float64) []float64 {
+	logs := make([]float64, len(data))
+	for i, x := range data {
+		logs[i] = math.Log1p(x)
+	}
+	return logs
+}
@@ -1,11 +1,7 @@
 package src
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"os"
 	"sort"
 )
 
@@ -18,12 +14,17 @@ type Bet struct {
 	ModelProbs   float64
 	Differential float64
 	EV           float64
-	Bets         []map[string]interface{}
+	Bets         []ArbitrageResult
 }
 
-func MakeBets(rr float64, maxbets int) []map[string]interface{} {
+// MakeBets combines the stored arbitrage results into bet slips and stores
+// them with a manifest listing the results they were drawn from
+func MakeBets(store Store, rr float64, maxbets int) ([]BetSlip, error) {
 	rr += 1.0
-	arbs := LoadData()
+	arbs, err := store.LoadArbitrage()
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize a slice to store Bet structs
 	var betsList []Bet
@@ -31,8 +32,7 @@ func MakeBets(rr float64, maxbets int) []map[string]interface{} {
 	fmt.Println(arbs)
 
 	// For each date in arbs
-	for _, arb := range arbs {
-		arblist := arb.([]map[string]interface{})
+	for _, arblist := range arbs {
 		var betCombos [][]int
 		// Get number of bets
 		numBets := len(arblist)
@@ -53,70 +53,57 @@ func MakeBets(rr float64, maxbets int) []map[string]interface{} {
 			bookProbs := 1.0
 			modelProbs := 1.0
 
-			actualBets := make([]map[string]interface{}, 0)
+			actualBets := make([]ArbitrageResult, 0)
 			betKeys := make(map[string]bool)        // To store unique bets
 			conflictKeys := make(map[string]string) // To detect over/under conflicts
 
 			// Calculate EV, bookProbs, and modelProbs for each combination
 			for _, i := range combo {
-				if arblist[i] != nil {
-					// Extract 'Bet' field
-					betData, ok := arblist[i]["Bet"].(map[string]interface{})
-					if !ok {
-						continue outerLoop
-					}
+				arb := arblist[i]
+				betData := arb.Bet
 
-					// Generate a unique key for the bet
-					betKey := generateBetKey(betData)
+				// Generate a unique key for the bet
+				betKey := generateBetKey(betData)
 
-					// Check if bet is already in the set
-					if betKeys[betKey] {
-						// Duplicate bet found, skip this combination
-						continue outerLoop
-					}
-					// Add betKey to the set
-					betKeys[betKey] = true
+				// Check if bet is already in the set
+				if betKeys[betKey] {
+					// Duplicate bet found, skip this combination
+					continue outerLoop
+				}
+				// Add betKey to the set
+				betKeys[betKey] = true
 
-					// Get 'name' field (Over or Under)
-					side, ok := betData["name"].(string)
-					if !ok {
-						continue outerLoop
-					}
+				// Get 'name' field (Over or Under)
+				side := betData.Name
+				if side == "" {
+					continue outerLoop
+				}
 
-					// Generate conflictKey (excluding 'name')
-					conflictKey := generateConflictKey(betData)
-
-					// Check for over/under conflicts
-					if existingSide, exists := conflictKeys[conflictKey]; exists {
-						if existingSide != side {
-							// Over and Under on same bet, skip combination
-							continue outerLoop
-						}
-					} else {
-						// Add to conflictKeys
-						conflictKeys[conflictKey] = side
-					}
+				// Generate conflictKey (excluding 'name')
+				conflictKey := generateConflictKey(betData)
 
-					// **New Check**: Exclude bets where BookProb > ModelProb
-					bookProb, ok1 := arblist[i]["BookProb"].(float64)
-					modelProb, ok2 := arblist[i]["ModelProb"].(float64)
-					if ok1 && ok2 && bookProb > modelProb {
-						// Skip this combination if any bet has BookProb > ModelProb
+				// Check for over/under conflicts
+				if existingSide, exists := conflictKeys[conflictKey]; exists {
+					if existingSide != side {
+						// Over and Under on same bet, skip combination
 						continue outerLoop
 					}
+				} else {
+					// Add to conflictKeys
+					conflictKeys[conflictKey] = side
+				}
 
-					// Now proceed to process the bet
-					if expectedValue, ok := arblist[i]["ExpectedValue"].(float64); ok {
-						EV *= expectedValue
-					}
-					if ok1 {
-						bookProbs *= bookProb
-					}
-					if ok2 {
-						modelProbs *= modelProb
-					}
-					actualBets = append(actualBets, arblist[i])
+				// Exclude bets where BookProb > ModelProb
+				if arb.BookProb > arb.ModelProb {
+					// Skip this combination if any bet has BookProb > ModelProb
+					continue outerLoop
 				}
+
+				// Now proceed to process the bet
+				EV *= arb.ExpectedValue
+				bookProbs *= arb.BookProb
+				modelProbs *= arb.ModelProb
+				actualBets = append(actualBets, arb)
 			}
 
 			// Calculate profits
@@ -166,12 +153,8 @@ func MakeBets(rr float64, maxbets int) []map[string]interface{} {
 
 		// Generate a unique key for the combination based on bet keys
 		comboBetKeys := []string{}
-		for _, betMap := range bet.Bets {
-			betData, ok := betMap["Bet"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			betKey := generateBetKey(betData)
+		for _, arb := range bet.Bets {
+			betKey := generateBetKey(arb.Bet)
 			comboBetKeys = append(comboBetKeys, betKey)
 		}
 
@@ -195,8 +178,8 @@ func MakeBets(rr float64, maxbets int) []map[string]interface{} {
 		return selectedBets[i].Differential > selectedBets[j].Differential
 	})
 
-	// Prepare the output as a slice of maps
-	var output []map[string]interface{}
+	// Prepare the output as bet slips
+	var output []BetSlip
 
 	// Loop through selectedBets and prepare the output
 	for _, bet := range selectedBets {
@@ -204,15 +187,15 @@ func MakeBets(rr float64, maxbets int) []map[string]interface{} {
 		comboKey := fmt.Sprintf("%v", bet.Combo)
 
 		// Add the actual bets and profit information to the output
-		output = append(output, map[string]interface{}{
-			"combo":        comboKey,
-			"bookProfit":   bet.BookProfit,
-			"bookProbs":    bet.BookProbs,
-			"modelProfit":  bet.ModelProfit,
-			"modelProbs":   bet.ModelProbs,
-			"differential": bet.Differential,
-			"EV":           bet.EV,
-			"bets":         bet.Bets, // Add the actual bets for this combination
+		output = append(output, BetSlip{
+			Combo:        comboKey,
+			BookProfit:   bet.BookProfit,
+			BookProbs:    bet.BookProbs,
+			ModelProfit:  bet.ModelProfit,
+			ModelProbs:   bet.ModelProbs,
+			Differential: bet.Differential,
+			EV:           bet.EV,
+			Bets:         bet.Bets, // Add the actual bets for this combination
 		})
 	}
 
@@ -237,39 +220,40 @@ func MakeBets(rr float64, maxbets int) []map[string]interface{} {
 		fmt.Println()
 	}
 
+	if err := store.SaveBets(output); err != nil {
+		return output, err
+	}
+	var inputs []ManifestInput
+	for _, scope := range SortedKeys(arbs) {
+		inputs = append(inputs, InputOf(scope+"/arbitrage.json", arbs[scope]))
+	}
+	if err := store.SaveManifest(NewManifest(BetsRef, inputs...)); err != nil {
+		return output, err
+	}
+
 	// Return the selected bets
-	return output
+	return output, nil
 }
 
 // Function to generate a unique key for each bet (including 'name')
-func generateBetKey(betData map[string]interface{}) string {
-	// Extract fields that uniquely define a bet, including 'name'
-	awayTeam := fmt.Sprintf("%v", betData["away_team"])
-	homeTeam := fmt.Sprintf("%v", betData["home_team"])
-	name := fmt.Sprintf("%v", betData["name"])
-	point := fmt.Sprintf("%v", betData["point"])
-	time := fmt.Sprintf("%v", betData["time"])
-	betType := fmt.Sprintf("%v", betData["type"])
-	description := fmt.Sprintf("%v", betData["description"])
-
-	// Combine them into a unique key
-	key := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s", awayTeam, homeTeam, name, point, time, betType, description)
-	return key
+func generateBetKey(betData BetOutcome) string {
+	// Combine the fields that uniquely define a bet, including 'name'
+	return fmt.Sprintf("%s|%s|%s|%s", betData.AwayTeam, betData.HomeTeam, betData.Name, generateOutcomeKey(betData))
 }
 
 // Function to generate a conflict key (excluding 'name')
-func generateConflictKey(betData map[string]interface{}) string {
-	// Extract fields that uniquely define a bet, excluding 'name'
-	awayTeam := fmt.Sprintf("%v", betData["away_team"])
-	homeTeam := fmt.Sprintf("%v", betData["home_team"])
-	point := fmt.Sprintf("%v", betData["point"])
-	time := fmt.Sprintf("%v", betData["time"])
-	betType := fmt.Sprintf("%v", betData["type"])
-	description := fmt.Sprintf("%v", betData["description"])
-
-	// Combine them into a conflict key
-	key := fmt.Sprintf("%s|%s|%s|%s|%s|%s", awayTeam, homeTeam, point, time, betType, description)
-	return key
+func generateConflictKey(betData BetOutcome) string {
+	// Combine the fields that uniquely define a bet, excluding 'name'
+	return fmt.Sprintf("%s|%s|%s", betData.AwayTeam, betData.HomeTeam, generateOutcomeKey(betData))
+}
+
+// generateOutcomeKey joins point, time, type and description
+func generateOutcomeKey(betData BetOutcome) string {
+	point := "<nil>"
+	if betData.Point != nil {
+		point = fmt.Sprintf("%v", *betData.Point)
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", point, betData.Time, betData.Type, betData.Description)
 }
 
 // Function to generate a unique key for a combination based on bet keys
@@ -277,57 +261,3 @@ func generateComboKeyFromBetKeys(betKeys []string) string {
 	// Since betKeys are sorted, we can concatenate them to form a unique key
 	return fmt.Sprintf("%v", betKeys)
 }
-
-func LoadData() map[string]interface{} {
-	arbs := make(map[string]interface{})
-	// read all folders in data folder (data => sport)
-	dir, err := ioutil.ReadDir("data")
-	if err != nil {
-		log.Fatal(err)
-	}
-	// sport dir
-	for _, folder := range dir {
-		years, err := ioutil.ReadDir("data/" + folder.Name())
-		if err != nil {
-			log.Fatal(err)
-		}
-		// year dir
-		for _, year := range years {
-			teams, err := ioutil.ReadDir("data/" + folder.Name() + "/" + year.Name())
-			if err != nil {
-				log.Fatal(err)
-			}
-			// date dir
-			for _, date := range teams {
-				data, err := ioutil.ReadDir("data/" + folder.Name() + "/" + year.Name() + "/" + date.Name())
-				if err != nil {
-					log.Fatal(err)
-				}
-				for _, file := range data {
-					if file.Name() == "arbitrage.json" {
-						// read file
-						jsonFile, err := os.Open("data/" + folder.Name() + "/" + year.Name() + "/" + date.Name() + "/" + file.Name())
-						if err != nil {
-							log.Fatal(err)
-						}
-						defer jsonFile.Close()
-
-						data, err := ioutil.ReadAll(jsonFile)
-						if err != nil {
-							log.Fatal(err)
-						}
-
-						var arbMap []map[string]interface{}
-						err = json.Unmarshal(data, &arbMap)
-						if err != nil {
-							log.Fatal(err)
-						}
-
-						arbs[folder.Name()+year.Name()+date.Name()] = arbMap
-					}
-				}
-			}
-		}
-	}
-	return arbs
-}
@@ -0,0 +1,291 @@
+package src
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the release of the tool, set with
+// -ldflags "-X betterbetter/src.Version=v1.2.3"
+var Version = "dev"
+
+// Manifest records how an output was produced
+type Manifest struct {
+	// Output is the reference of the output, its path under the data directory
+	Output  string            `json:"output"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Params  map[string]string `json:"params"`
+	Inputs  []ManifestInput   `json:"inputs"`
+	// StartedAt is when the run began, WrittenAt when the output was saved
+	StartedAt string `json:"startedAt"`
+	WrittenAt string `json:"writtenAt"`
+	Version   string `json:"version"`
+	Storage   string `json:"storage"`
+	// Models are the models of each metric, for predictions
+	Models map[string]ModelRecord `json:"models,omitempty"`
+	// Diagnostics are the convergence diagnostics of each metric's model and
+	// the thresholds they failed, for predictions
+	Diagnostics map[string]DiagnosticsRecord `json:"diagnostics,omitempty"`
+}
+
+// ManifestInput is one input of an output: another stored output or a
+// provider request, with the SHA-256 of the content that was used
+type ManifestInput struct {
+	Ref    string `json:"ref"`
+	SHA256 string `json:"sha256"`
+}
+
+// runInfo describes the command being run
+type runInfo struct {
+	command string
+	args    []string
+	params  map[string]string
+	started time.Time
+}
+
+var (
+	runMu      sync.Mutex
+	currentRun = runInfo{command: "betterbetter", started: time.Now().UTC()}
+)
+
+// StartRun records the command, arguments and flag values that every
+// manifest written by this process reports
+func StartRun(command string, args []string, params map[string]string) {
+	runMu.Lock()
+	defer runMu.Unlock()
+	currentRun = runInfo{command: command, args: args, params: params, started: time.Now().UTC()}
+}
+
+// NewManifest describes output as produced by the current run from inputs
+func NewManifest(output string, inputs ...ManifestInput) Manifest {
+	runMu.Lock()
+	run := currentRun
+	runMu.Unlock()
+
+	params := make(map[string]string, len(run.params))
+	for k, v := range run.params {
+		params[k] = v
+	}
+	return Manifest{
+		Output:    output,
+		Command:   run.command,
+		Args:      run.args,
+		Params:    params,
+		Inputs:    inputs,
+		StartedAt: run.started.Format(time.RFC3339),
+		WrittenAt: time.Now().UTC().Format(time.RFC3339),
+		Version:   ToolVersion(),
+		Storage:   StorageBackend,
+	}
+}
+
+// ToolVersion is Version plus the VCS revision the binary was built from
+func ToolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Version
+	}
+	version := Version
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			version += " " + s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				version += " (modified)"
+			}
+		}
+	}
+	return version
+}
+
+// InputOf hashes v as it is encoded in JSON
+func InputOf(ref string, v interface{}) ManifestInput {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return ManifestInput{Ref: ref}
+	}
+	return InputOfRaw(ref, string(encoded))
+}
+
+// InputOfRaw hashes a raw body such as a provider response
+func InputOfRaw(ref string, body string) ManifestInput {
+	sum := sha256.Sum256([]byte(body))
+	return ManifestInput{Ref: ref, SHA256: hex.EncodeToString(sum[:])}
+}
+
+// RequestRef names a provider request, e.g.
+// api-sports:nba/team-stats?season=2024&team=2
+func RequestRef(provider string, sport string, requestType string, params map[string]string) string {
+	keys := SortedKeys(params)
+	query := make([]string, len(keys))
+	for i, k := range keys {
+		query[i] = k + "=" + params[k]
+	}
+	ref := provider + ":" + sport + "/" + requestType
+	if len(query) > 0 {
+		ref += "?" + strings.Join(query, "&")
+	}
+	return ref
+}
+
+// MergeInputs appends inputs whose ref is not already listed
+func MergeInputs(existing []ManifestInput, inputs ...ManifestInput) []ManifestInput {
+	index := make(map[string]int, len(existing))
+	merged := append([]ManifestInput{}, existing...)
+	for i, in := range merged {
+		index[in.Ref] = i
+	}
+	for _, in := range inputs {
+		if i, ok := index[in.Ref]; ok {
+			merged[i] = in
+			continue
+		}
+		index[in.Ref] = len(merged)
+		merged = append(merged, in)
+	}
+	return merged
+}
+
+// SaveMergedManifest saves the manifest of an output that merges new data
+// into what was stored, keeping the inputs of earlier runs
+func SaveMergedManifest(store Store, m Manifest) error {
+	previous, ok, err := store.LoadManifest(m.Output)
+	if err != nil {
+		return err
+	}
+	if ok {
+		m.Inputs = MergeInputs(previous.Inputs, m.Inputs...)
+	}
+	return store.SaveManifest(m)
+}
+
+// Output references: the path of each output in the JSON tree, relative to
+// the data directory, whichever backend stores it
+
+func (k DataKey) GamesRef() string {
+	return k.String() + "/games.json"
+}
+
+func (k DataKey) StatsRef() string {
+	return k.String() + "/" + statsFile(k)
+}
+
+func (k DataKey) DocumentRef(name string) string {
+	return k.String() + "/" + name
+}
+
+func (k DataKey) PredsRef(player string) string {
+	return k.String() + "/preds/" + player + "_preds.json"
+}
+
+func (s OddsScope) ArbitrageRef() string {
+	return s.String() + "/arbitrage.json"
+}
+
+func (k EventKey) OddsRef() string {
+	return k.Sport + "/" + k.Date[:4] + "/" + k.Date + "/" + k.Event + "/odds.json"
+}
+
+func (k EventKey) SnapshotRef(label string, timestamp time.Time) string {
+	return k.Sport + "/" + k.Date[:4] + "/" + k.Date + "/" + k.Event + "/" + HistoryDir + "/" + timestamp.UTC().Format(snapshotTimeFormat) + "_" + label + ".json"
+}
+
+// BetsRef is the output of makebets
+const BetsRef = "bets.json"
+
+// RefFromPath turns a path under the data directory, or the path of its
+// manifest, into an output reference
+func RefFromPath(path string) string {
+	ref := strings.Join(dataParts(path), "/")
+	if IsManifest(ref) {
+		ref = strings.TrimSuffix(ref, manifestSuffix) + ".json"
+	}
+	return ref
+}
+
+const manifestSuffix = ".manifest.json"
+
+// IsManifest reports whether a file name is a manifest rather than an output
+func IsManifest(name string) bool {
+	return strings.HasSuffix(name, manifestSuffix)
+}
+
+// ManifestPath is where the manifest of the file at path is written
+func ManifestPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + manifestSuffix
+}
+
+// SaveFileManifest writes the manifest of a file kept outside the store,
+// such as clv.json, next to it
+func SaveFileManifest(path string, m Manifest) error {
+	path = ManifestPath(path)
+	return SaveToFile(m, filepath.Dir(path), filepath.Base(path))
+}
+
+// LoadFileManifest reads the manifest next to the file at path
+func LoadFileManifest(path string) (Manifest, bool, error) {
+	var m Manifest
+	raw, err := os.ReadFile(ManifestPath(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, false, nil
+		}
+		return m, false, fmt.Errorf("failed to read manifest of %s: %v", path, err)
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return m, false, fmt.Errorf("failed to decode manifest of %s: %v", path, err)
+	}
+	return m, true, nil
+}
+
+// Lineage is an output's manifest with the lineage of every input that has
+// one of its own
+type Lineage struct {
+	Manifest Manifest
+	Inputs   map[string]*Lineage
+}
+
+// TraceLineage follows manifests from ref back to provider requests
+func TraceLineage(store Store, m Manifest) (*Lineage, error) {
+	return traceLineage(store, m, map[string]bool{m.Output: true})
+}
+
+func traceLineage(store Store, m Manifest, seen map[string]bool) (*Lineage, error) {
+	lineage := &Lineage{Manifest: m, Inputs: map[string]*Lineage{}}
+	refs := make([]string, 0, len(m.Inputs))
+	for _, in := range m.Inputs {
+		refs = append(refs, in.Ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		input, ok, err := store.LoadManifest(ref)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		seen[ref] = true
+		parent, err := traceLineage(store, input, seen)
+		if err != nil {
+			return nil, err
+		}
+		lineage.Inputs[ref] = parent
+	}
+	return lineage, nil
+}
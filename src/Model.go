@@ -0,0 +1,399 @@
+package src
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelFile is a declarative description of the models bayes fits. A
+// metric's spec starts from Default, then is overridden by Metrics, the
+// sport's Default and the sport's Metrics, in that order.
+type ModelFile struct {
+	Default ModelSpec              `yaml:"default"`
+	Metrics map[string]ModelSpec   `yaml:"metrics"`
+	Sports  map[string]SportModels `yaml:"sports"`
+
+	path string
+	raw  []byte
+}
+
+// SportModels overrides the model specs of one sport
+type SportModels struct {
+	Default ModelSpec            `yaml:"default"`
+	Metrics map[string]ModelSpec `yaml:"metrics"`
+}
+
+// ModelSpec describes the model of one metric. Unset fields are inherited
+// from the spec it overrides.
+type ModelSpec struct {
+	Likelihood string `yaml:"likelihood" json:"likelihood,omitempty"`
+	// Trials is the number of trials of a Binomial likelihood
+	Trials int `yaml:"trials" json:"trials,omitempty"`
+	// Link is a registered link name; unset, count likelihoods use log,
+	// Binomial logit and the rest normal
+	Link       string          `yaml:"link" json:"link,omitempty"`
+	Covariates CovariateSpec   `yaml:"covariates" json:"covariates"`
+	Priors     PriorSpecs      `yaml:"priors" json:"priors"`
+	Sampler    string          `yaml:"sampler" json:"sampler,omitempty"`
+	Settings   SamplerSettings `yaml:"sampler_settings" json:"sampler_settings"`
+}
+
+// CovariateSpec lists the features the link is given
+type CovariateSpec struct {
+	Lags int `yaml:"lags" json:"lags,omitempty"` // previous observations of the metric
+}
+
+// PriorSpecs holds the priors of a model's parameters
+type PriorSpecs struct {
+	Lag       *PriorSpec `yaml:"lag" json:"lag,omitempty"` // every lag coefficient
+	Intercept *PriorSpec `yaml:"intercept" json:"intercept,omitempty"`
+	// Params are the likelihood parameters the link passes through, by name
+	Params map[string]PriorSpec `yaml:"params" json:"params,omitempty"`
+}
+
+// PriorSpec is a distribution whose parameters are numbers or a statistic
+// of the metric's observations: mean, sd, log_mean (log(mean + 0.5)),
+// sqrt1p_mean (sqrt(1 + mean)) or, with trials, logit_rate (the log odds of
+// (mean + 0.5) / (trials + 1))
+type PriorSpec struct {
+	Dist   string            `yaml:"dist" json:"dist,omitempty"`
+	Params map[string]string `yaml:"params" json:"params,omitempty"`
+}
+
+// SamplerSettings tune the sampler; zero values keep its defaults
+type SamplerSettings struct {
+	GridSize int `yaml:"grid_size" json:"grid_size,omitempty"` // prior draws per parameter for grid samplers
+	Steps    int `yaml:"steps" json:"steps,omitempty"`         // draws kept per chain
+	Warmup   int `yaml:"warmup" json:"warmup,omitempty"`       // draws discarded before them
+}
+
+// Model is a ModelSpec resolved against a metric's observations. Its Spec
+// has every default filled in.
+type Model struct {
+	Spec         ModelSpec
+	Priors       []DistributionParams
+	Likelihood   DistributionParams
+	Link         func([]float64, []float64) []float64
+	LinkJacobian LinkJacobian
+	ParamNames   []string // one per coordinate of the point
+}
+
+var samplers = []string{"Metropolis", "Adaptive", "NUTS", "Hamiltonian", "Unit", "Lattice", "Gaussian"}
+
+var (
+	defaultLagPrior = PriorSpec{Dist: "Uniform", Params: map[string]string{"Min": "0", "Max": "1"}}
+	// defaultParamPriors are the priors of the parameters count likelihoods
+	// have after their mean
+	defaultParamPriors = map[string]PriorSpec{
+		"Alpha": {Dist: "Exponential", Params: map[string]string{"Rate": "0.1"}},
+		"Pi":    {Dist: "Uniform", Params: map[string]string{"Min": "0", "Max": "1"}},
+	}
+)
+
+// LoadModelFile reads a YAML (or JSON) model file, rejecting unknown fields
+func LoadModelFile(path string) (*ModelFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model file %s: %v", path, err)
+	}
+	f := &ModelFile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(f); err != nil {
+		return nil, fmt.Errorf("failed to parse model file %s: %v", path, err)
+	}
+	f.path, f.raw = path, raw
+	return f, nil
+}
+
+// Input is the model file as a manifest input, hashed as it was read
+func (f *ModelFile) Input() ManifestInput {
+	return InputOfRaw("model:"+filepath.ToSlash(f.path), string(f.raw))
+}
+
+// Spec returns the model of a sport's metric, starting from base. A nil
+// file returns base.
+func (f *ModelFile) Spec(base ModelSpec, sport string, metric string) ModelSpec {
+	if f == nil {
+		return base
+	}
+	spec := base.merge(f.Default).merge(f.Metrics[metric])
+	if s, ok := f.Sports[sport]; ok {
+		spec = spec.merge(s.Default).merge(s.Metrics[metric])
+	}
+	return spec
+}
+
+// merge overrides the fields of s that are set in o
+func (s ModelSpec) merge(o ModelSpec) ModelSpec {
+	if o.Likelihood != "" {
+		s.Likelihood = o.Likelihood
+	}
+	if o.Trials != 0 {
+		s.Trials = o.Trials
+	}
+	if o.Link != "" {
+		s.Link = o.Link
+	}
+	if o.Covariates.Lags != 0 {
+		s.Covariates.Lags = o.Covariates.Lags
+	}
+	if o.Priors.Lag != nil {
+		s.Priors.Lag = o.Priors.Lag
+	}
+	if o.Priors.Intercept != nil {
+		s.Priors.Intercept = o.Priors.Intercept
+	}
+	if len(o.Priors.Params) > 0 {
+		params := make(map[string]PriorSpec, len(s.Priors.Params)+len(o.Priors.Params))
+		for k, v := range s.Priors.Params {
+			params[k] = v
+		}
+		for k, v := range o.Priors.Params {
+			params[k] = v
+		}
+		s.Priors.Params = params
+	}
+	if o.Sampler != "" {
+		s.Sampler = o.Sampler
+	}
+	if o.Settings.GridSize != 0 {
+		s.Settings.GridSize = o.Settings.GridSize
+	}
+	if o.Settings.Steps != 0 {
+		s.Settings.Steps = o.Settings.Steps
+	}
+	if o.Settings.Warmup != 0 {
+		s.Settings.Warmup = o.Settings.Warmup
+	}
+	return s
+}
+
+// Validate checks that a spec names a known likelihood, link and sampler
+// that fit together and that every parameter has a prior
+func (s ModelSpec) Validate() error {
+	_, err := s.Build(nil)
+	return err
+}
+
+// Build resolves the spec against a metric's observations: priors with
+// their statistics filled in, the likelihood and the link
+func (s ModelSpec) Build(metric []float64) (Model, error) {
+	model := Model{Spec: s}
+	dist := s.likelihood()
+	keys := getParamKeys(dist)
+	if len(keys) == 0 {
+		return model, fmt.Errorf("unsupported likelihood %q", dist)
+	}
+	if s.Covariates.Lags < 1 {
+		return model, fmt.Errorf("a model needs at least one lag, got %d", s.Covariates.Lags)
+	}
+	if s.Sampler != "" && !slices.Contains(samplers, s.Sampler) {
+		return model, fmt.Errorf("unknown sampler %q", s.Sampler)
+	}
+	linkName := s.link()
+	link, err := GetLink(linkName)
+	if err != nil {
+		return model, err
+	}
+	fixed, err := s.fixedParams(dist)
+	if err != nil {
+		return model, err
+	}
+	if link.Params > len(keys)-len(fixed) {
+		return model, fmt.Errorf("link %s sets %d parameters but %s has %d", linkName, link.Params, dist, len(keys)-len(fixed))
+	}
+
+	stats := metricStats(metric, s.Trials)
+	lagPrior := defaultLagPrior
+	if s.Priors.Lag != nil {
+		lagPrior = *s.Priors.Lag
+	}
+	for i := 0; i < s.Covariates.Lags; i++ {
+		prior, err := lagPrior.resolve(stats)
+		if err != nil {
+			return model, fmt.Errorf("lag prior: %v", err)
+		}
+		model.Priors = append(model.Priors, prior)
+		model.ParamNames = append(model.ParamNames, fmt.Sprintf("lag %d", i+1))
+	}
+	model.Spec.Priors.Lag = &lagPrior
+
+	interceptPrior := PriorSpec{Dist: "Normal", Params: map[string]string{"Mu": "mean", "Sigma": "sqrt1p_mean"}}
+	switch linkName {
+	case "log":
+		interceptPrior = PriorSpec{Dist: "Normal", Params: map[string]string{"Mu": "log_mean", "Sigma": "1"}}
+	case "logit":
+		interceptPrior = PriorSpec{Dist: "Normal", Params: map[string]string{"Mu": "logit_rate", "Sigma": "1"}}
+	}
+	if s.Priors.Intercept != nil {
+		interceptPrior = *s.Priors.Intercept
+	}
+	prior, err := interceptPrior.resolve(stats)
+	if err != nil {
+		return model, fmt.Errorf("intercept prior: %v", err)
+	}
+	model.Priors = append(model.Priors, prior)
+	model.ParamNames = append(model.ParamNames, "intercept")
+	model.Spec.Priors.Intercept = &interceptPrior
+	model.Spec.Priors.Params = map[string]PriorSpec{}
+
+	for _, key := range keys[link.Params:] {
+		if _, ok := fixed[key]; ok {
+			continue
+		}
+		spec, ok := s.Priors.Params[key]
+		if !ok {
+			if spec, ok = defaultParamPriors[key]; !ok {
+				return model, fmt.Errorf("no prior for %s parameter %s", dist, key)
+			}
+		}
+		prior, err := spec.resolve(stats)
+		if err != nil {
+			return model, fmt.Errorf("%s prior: %v", key, err)
+		}
+		model.Priors = append(model.Priors, prior)
+		model.ParamNames = append(model.ParamNames, key)
+		model.Spec.Priors.Params[key] = spec
+	}
+
+	model.Likelihood = DistributionParams{Dist: dist, Params: fixed}
+	model.Spec.Likelihood = dist
+	model.Spec.Link = linkName
+	model.Link, model.LinkJacobian = link.Build(s.Covariates.Lags)
+	return model, nil
+}
+
+// ModelRecord is the model a metric's predictions were made with, as their
+// manifest reports it
+type ModelRecord struct {
+	Spec   ModelSpec     `json:"spec"`
+	Priors []PriorRecord `json:"priors"`
+}
+
+// PriorRecord is the prior of one parameter with its statistics filled in
+type PriorRecord struct {
+	Param  string             `json:"param"`
+	Dist   string             `json:"dist"`
+	Params map[string]float64 `json:"params"`
+}
+
+// Record describes the model as sampled by mc, with the sampler, grid size
+// and draws it ran with
+func (m Model) Record(mc MarkovChain) ModelRecord {
+	spec := m.Spec
+	spec.Sampler = mc.Sampler
+	spec.Settings.GridSize = mc.SampleSize
+	spec.Settings.Steps, spec.Settings.Warmup = mc.draws()
+
+	priors := make([]PriorRecord, len(m.Priors))
+	for i, prior := range m.Priors {
+		priors[i] = PriorRecord{Param: m.ParamNames[i], Dist: prior.Dist, Params: prior.Params}
+	}
+	return ModelRecord{Spec: spec, Priors: priors}
+}
+
+// NewMarkovChain returns a chain over the model's priors with the spec's
+// sampler and settings
+func (m Model) NewMarkovChain(likelihood Likelihood) MarkovChain {
+	gridSize := m.Spec.Settings.GridSize
+	if gridSize == 0 {
+		gridSize = 25
+	}
+	sampler := m.Spec.Sampler
+	if sampler == "" {
+		sampler = "Metropolis"
+	}
+	return MarkovChain{
+		Distributions: m.Priors,
+		Likelihood:    likelihood,
+		SampleSize:    gridSize,
+		Sampler:       sampler,
+		Steps:         m.Spec.Settings.Steps,
+		Warmup:        m.Spec.Settings.Warmup,
+	}
+}
+
+func (s ModelSpec) likelihood() string {
+	if s.Likelihood == "" {
+		return "Normal"
+	}
+	return s.Likelihood
+}
+
+// fixedParams are the likelihood parameters the spec sets outright, which
+// come after every parameter the link sets
+func (s ModelSpec) fixedParams(dist string) (map[string]float64, error) {
+	fixed := map[string]float64{}
+	if dist == "Binomial" {
+		if s.Trials < 1 {
+			return nil, fmt.Errorf("a Binomial likelihood needs trials, got %d", s.Trials)
+		}
+		fixed["N"] = float64(s.Trials)
+	}
+	return fixed, nil
+}
+
+func (s ModelSpec) link() string {
+	switch {
+	case s.Link != "":
+		return s.Link
+	case IsCountLikelihood(s.likelihood()):
+		return "log"
+	case s.likelihood() == "Binomial":
+		return "logit"
+	default:
+		return "normal"
+	}
+}
+
+// resolve fills in the statistics a prior's parameters name
+func (p PriorSpec) resolve(stats map[string]float64) (DistributionParams, error) {
+	prior := DistributionParams{Dist: p.Dist, Params: make(map[string]float64, len(p.Params))}
+	if prior.CreateDist(nil) == nil {
+		return prior, fmt.Errorf("unsupported distribution %q", p.Dist)
+	}
+	for name, value := range p.Params {
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			prior.Params[name] = v
+			continue
+		}
+		v, ok := stats[value]
+		if !ok {
+			return prior, fmt.Errorf("%s: %q is neither a number nor mean, sd, log_mean, sqrt1p_mean or logit_rate", name, value)
+		}
+		prior.Params[name] = v
+	}
+	return prior, nil
+}
+
+// metricStats are the statistics of a metric a prior can refer to;
+// logit_rate needs the number of trials
+func metricStats(metric []float64, trials int) map[string]float64 {
+	mean, sd := 0.0, 0.0
+	if len(metric) > 0 {
+		mean = Sum(metric) / float64(len(metric))
+		for _, x := range metric {
+			sd += (x - mean) * (x - mean)
+		}
+		sd = math.Sqrt(sd / float64(len(metric)))
+	}
+	stats := map[string]float64{
+		"mean":        mean,
+		"sd":          sd,
+		"log_mean":    math.Log(mean + 0.5),
+		"sqrt1p_mean": math.Sqrt(1 + mean),
+	}
+	if trials > 0 {
+		rate := (mean + 0.5) / float64(trials+1)
+		stats["logit_rate"] = math.Log(rate) - math.Log1p(-rate)
+	}
+	return stats
+}
@@ -0,0 +1,136 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const layeredModels = `
+default:
+  likelihood: Poisson
+  covariates: {lags: 4}
+  sampler: Metropolis
+  sampler_settings: {grid_size: 20}
+metrics:
+  blocks:
+    likelihood: NegativeBinomial
+    sampler: Adaptive
+  steals:
+    covariates: {lags: 3}
+    priors:
+      params:
+        Pi: {dist: Beta, params: {Alpha: 1, Beta: 3}}
+sports:
+  nba:
+    default:
+      sampler: NUTS
+      sampler_settings: {steps: 800}
+    metrics:
+      steals:
+        likelihood: ZeroInflatedPoisson
+        priors:
+          params:
+            Alpha: {dist: Exponential, params: {Rate: 1}}
+`
+
+func writeModelFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestModelFileLayering(t *testing.T) {
+	f, err := LoadModelFile(writeModelFile(t, layeredModels))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := ModelSpec{Likelihood: "Normal", Sampler: "Unit", Covariates: CovariateSpec{Lags: 2}}
+
+	cases := []struct {
+		sport, metric string
+		likelihood    string
+		lags          int
+		sampler       string
+		steps         int
+		priors        []string // likelihood parameters with a prior
+	}{
+		// default overrides the flags
+		{"nfl", "points", "Poisson", 4, "Metropolis", 0, nil},
+		// metrics override default
+		{"nfl", "blocks", "NegativeBinomial", 4, "Adaptive", 0, nil},
+		{"nfl", "steals", "Poisson", 3, "Metropolis", 0, []string{"Pi"}},
+		// the sport's default overrides metrics
+		{"nba", "blocks", "NegativeBinomial", 4, "NUTS", 800, nil},
+		// the sport's metrics override everything, merging priors by name
+		{"nba", "steals", "ZeroInflatedPoisson", 3, "NUTS", 800, []string{"Alpha", "Pi"}},
+	}
+	for _, c := range cases {
+		s := f.Spec(base, c.sport, c.metric)
+		if s.Likelihood != c.likelihood || s.Covariates.Lags != c.lags || s.Sampler != c.sampler || s.Settings.Steps != c.steps {
+			t.Errorf("%s %s: got %s, %d lags, %s, %d steps; want %s, %d lags, %s, %d steps", c.sport, c.metric,
+				s.Likelihood, s.Covariates.Lags, s.Sampler, s.Settings.Steps, c.likelihood, c.lags, c.sampler, c.steps)
+		}
+		if s.Settings.GridSize != 20 {
+			t.Errorf("%s %s: grid size %d not inherited from default", c.sport, c.metric, s.Settings.GridSize)
+		}
+		if got := SortedKeys(s.Priors.Params); strings.Join(got, ",") != strings.Join(c.priors, ",") {
+			t.Errorf("%s %s: priors for %v, want %v", c.sport, c.metric, got, c.priors)
+		}
+	}
+
+	if s := (*ModelFile)(nil).Spec(base, "nba", "steals"); s.Likelihood != "Normal" || s.Sampler != "Unit" {
+		t.Errorf("a nil model file changed the flags' spec: %+v", s)
+	}
+}
+
+func TestModelFileRejectsUnknownFields(t *testing.T) {
+	if _, err := LoadModelFile(writeModelFile(t, "default:\n  likelihod: Poisson\n")); err == nil {
+		t.Error("a misspelled field was accepted")
+	}
+}
+
+func TestExampleModelFileValidates(t *testing.T) {
+	f, err := LoadModelFile(filepath.Join("..", "models.example.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := ModelSpec{Likelihood: "Normal", Covariates: CovariateSpec{Lags: 4}}
+	for _, metric := range []string{"points", "blocks", "steals", "turnovers"} {
+		if err := f.Spec(base, "nba", metric).Validate(); err != nil {
+			t.Errorf("nba %s: %v", metric, err)
+		}
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	lags := CovariateSpec{Lags: 2}
+	cases := []struct {
+		name string
+		spec ModelSpec
+		err  string
+	}{
+		{"likelihood", ModelSpec{Likelihood: "Cauchy", Covariates: lags}, "unsupported likelihood"},
+		{"lags", ModelSpec{Likelihood: "Poisson"}, "at least one lag"},
+		{"sampler", ModelSpec{Likelihood: "Poisson", Covariates: lags, Sampler: "Gibbs"}, "unknown sampler"},
+		{"link name", ModelSpec{Likelihood: "Poisson", Covariates: lags, Link: "probit"}, "probit"},
+		{"link fit", ModelSpec{Likelihood: "Poisson", Covariates: lags, Link: "normal"}, "link normal sets 2 parameters"},
+		{"trials", ModelSpec{Likelihood: "Binomial", Covariates: lags}, "trials"},
+		{"missing prior", ModelSpec{Likelihood: "Normal", Covariates: lags, Link: "identity"}, "no prior for Normal parameter Sigma"},
+		{"statistic", ModelSpec{Likelihood: "Poisson", Covariates: lags,
+			Priors: PriorSpecs{Intercept: &PriorSpec{Dist: "Normal", Params: map[string]string{"Mu": "median", "Sigma": "1"}}}}, "intercept prior"},
+	}
+	for _, c := range cases {
+		err := c.spec.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: got error %v, want one mentioning %q", c.name, err, c.err)
+		}
+	}
+
+	if err := (ModelSpec{Likelihood: "Binomial", Trials: 10, Covariates: lags}).Validate(); err != nil {
+		t.Errorf("valid Binomial spec: %v", err)
+	}
+}
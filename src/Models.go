@@ -0,0 +1,276 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ApiSportsEnvelope is the wrapper every api-sports.io response shares
+type ApiSportsEnvelope[T any] struct {
+	Get        string          `json:"get"`
+	Parameters json.RawMessage `json:"parameters"`
+	Errors     json.RawMessage `json:"errors"`
+	Results    int             `json:"results"`
+	Response   []T             `json:"response"`
+}
+
+type TeamRef struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Nickname string `json:"nickname"`
+	Code     string `json:"code"`
+	Logo     string `json:"logo"`
+}
+
+type Team struct {
+	TeamRef
+	City         string `json:"city"`
+	AllStar      bool   `json:"allStar"`
+	NbaFranchise bool   `json:"nbaFranchise"`
+}
+
+type Player struct {
+	ID        int    `json:"id"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Birth     struct {
+		Date    string `json:"date"`
+		Country string `json:"country"`
+	} `json:"birth"`
+}
+
+type PlayerRef struct {
+	ID        int    `json:"id"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+}
+
+// PlayerGameStats is one player's box score line for one game
+type PlayerGameStats struct {
+	Player PlayerRef `json:"player"`
+	Team   TeamRef   `json:"team"`
+	Game   struct {
+		ID int `json:"id"`
+	} `json:"game"`
+	Points    float64 `json:"points"`
+	Pos       string  `json:"pos"`
+	Min       string  `json:"min"`
+	Fgm       float64 `json:"fgm"`
+	Fga       float64 `json:"fga"`
+	Ftm       float64 `json:"ftm"`
+	Fta       float64 `json:"fta"`
+	Tpm       float64 `json:"tpm"`
+	Tpa       float64 `json:"tpa"`
+	OffReb    float64 `json:"offReb"`
+	DefReb    float64 `json:"defReb"`
+	TotReb    float64 `json:"totReb"`
+	Assists   float64 `json:"assists"`
+	PFouls    float64 `json:"pFouls"`
+	Steals    float64 `json:"steals"`
+	Turnovers float64 `json:"turnovers"`
+	Blocks    float64 `json:"blocks"`
+	PlusMinus string  `json:"plusMinus"`
+}
+
+type GameScore struct {
+	Win       int      `json:"win"`
+	Loss      int      `json:"loss"`
+	Linescore []string `json:"linescore"`
+	Points    float64  `json:"points"`
+}
+
+type Game struct {
+	ID     int    `json:"id"`
+	League string `json:"league"`
+	Season int    `json:"season"`
+	Date   struct {
+		Start    string `json:"start"`
+		End      string `json:"end"`
+		Duration string `json:"duration"`
+	} `json:"date"`
+	Status struct {
+		Short int    `json:"short"`
+		Long  string `json:"long"`
+	} `json:"status"`
+	Teams struct {
+		Visitors TeamRef `json:"visitors"`
+		Home     TeamRef `json:"home"`
+	} `json:"teams"`
+	Scores struct {
+		Visitors GameScore `json:"visitors"`
+		Home     GameScore `json:"home"`
+	} `json:"scores"`
+}
+
+// OddsEvent is a scheduled game on the-odds-api
+type OddsEvent struct {
+	ID           string `json:"id"`
+	SportKey     string `json:"sport_key"`
+	SportTitle   string `json:"sport_title"`
+	CommenceTime string `json:"commence_time"`
+	HomeTeam     string `json:"home_team"`
+	AwayTeam     string `json:"away_team"`
+}
+
+type Outcome struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Price       float64  `json:"price"`
+	Point       *float64 `json:"point,omitempty"`
+}
+
+type Market struct {
+	Key        string    `json:"key"`
+	LastUpdate string    `json:"last_update"`
+	Outcomes   []Outcome `json:"outcomes"`
+}
+
+type Bookmaker struct {
+	Key        string   `json:"key"`
+	Title      string   `json:"title"`
+	LastUpdate string   `json:"last_update"`
+	Markets    []Market `json:"markets"`
+}
+
+// EventOdds is an OddsEvent with its bookmaker prices
+type EventOdds struct {
+	OddsEvent
+	Bookmakers []Bookmaker `json:"bookmakers"`
+}
+
+// HistoricalSnapshot is the wrapper the-odds-api puts around historical data
+type HistoricalSnapshot[T any] struct {
+	Timestamp         string `json:"timestamp"`
+	PreviousTimestamp string `json:"previous_timestamp"`
+	NextTimestamp     string `json:"next_timestamp"`
+	Data              T      `json:"data"`
+}
+
+// BetOutcome is an odds outcome flattened with its game, as stored in
+// arbitrage.json and bets.json
+type BetOutcome struct {
+	Outcome
+	Time      string `json:"time"`
+	HomeTeam  string `json:"home_team"`
+	AwayTeam  string `json:"away_team"`
+	Type      string `json:"type,omitempty"`
+	Market    string `json:"market,omitempty"`
+	Bookmaker string `json:"bookmaker,omitempty"`
+}
+
+// ArbitrageResult compares the model probability of one outcome to the book
+type ArbitrageResult struct {
+	ExpectedValue float64    `json:"ExpectedValue"`
+	BookProb      float64    `json:"BookProb"`
+	ModelProb     float64    `json:"ModelProb"`
+	Differential  float64    `json:"Differential"`
+	Bet           BetOutcome `json:"Bet"`
+}
+
+// BetSlip is one entry of bets.json as written by the makebets command
+type BetSlip struct {
+	Combo        string            `json:"combo"`
+	BookProfit   float64           `json:"bookProfit"`
+	BookProbs    float64           `json:"bookProbs"`
+	ModelProfit  float64           `json:"modelProfit"`
+	ModelProbs   float64           `json:"modelProbs"`
+	Differential float64           `json:"differential"`
+	EV           float64           `json:"EV"`
+	Bets         []ArbitrageResult `json:"bets"`
+}
+
+func decodeApiSports[T any](data string, what string) ([]T, error) {
+	var envelope ApiSportsEnvelope[T]
+	if err := json.Unmarshal([]byte(data), &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %v", what, err)
+	}
+	if apiErr := apiSportsError(envelope.Errors); apiErr != "" {
+		return nil, fmt.Errorf("api-sports returned errors for %s: %s", what, apiErr)
+	}
+	return envelope.Response, nil
+}
+
+// apiSportsError returns the errors field when it is a non-empty object or
+// array; api-sports sends [] on success and {"key": "message"} on failure
+func apiSportsError(raw json.RawMessage) string {
+	trimmed := bytes.TrimSpace(raw)
+	switch string(trimmed) {
+	case "", "null", "[]", "{}":
+		return ""
+	}
+	return string(trimmed)
+}
+
+func DecodeTeams(data string) ([]Team, error) {
+	return decodeApiSports[Team](data, "teams")
+}
+
+func DecodePlayers(data string) ([]Player, error) {
+	return decodeApiSports[Player](data, "players")
+}
+
+func DecodePlayerGameStats(data string) ([]PlayerGameStats, error) {
+	return decodeApiSports[PlayerGameStats](data, "player statistics")
+}
+
+func DecodeGames(data string) ([]Game, error) {
+	return decodeApiSports[Game](data, "games")
+}
+
+// DecodeOddsEvents decodes a historical events response
+func DecodeOddsEvents(data string) (HistoricalSnapshot[[]OddsEvent], error) {
+	var snapshot HistoricalSnapshot[[]OddsEvent]
+	if err := decodeOddsApi(data, &snapshot, "events"); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// DecodeEventOdds decodes a historical event odds response
+func DecodeEventOdds(data string) (HistoricalSnapshot[EventOdds], error) {
+	var snapshot HistoricalSnapshot[EventOdds]
+	if err := decodeOddsApi(data, &snapshot, "event odds"); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Data.ID == "" {
+		return snapshot, fmt.Errorf("event odds response has no event data")
+	}
+	return snapshot, nil
+}
+
+// decodeOddsApi unmarshals into v, surfacing the-odds-api error messages
+func decodeOddsApi(data string, v any, what string) error {
+	var apiErr struct {
+		Message   string `json:"message"`
+		ErrorCode string `json:"error_code"`
+	}
+	if json.Unmarshal([]byte(data), &apiErr) == nil && apiErr.Message != "" {
+		return fmt.Errorf("the-odds-api returned an error for %s: %s (%s)", what, apiErr.Message, apiErr.ErrorCode)
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %v", what, err)
+	}
+	return nil
+}
+
+// FlattenOutcomes lists every outcome of an event by market key, annotated
+// with the game it belongs to
+func FlattenOutcomes(event EventOdds) map[string][]BetOutcome {
+	outcomes := make(map[string][]BetOutcome)
+	for _, bookmaker := range event.Bookmakers {
+		for _, market := range bookmaker.Markets {
+			for _, outcome := range market.Outcomes {
+				outcomes[market.Key] = append(outcomes[market.Key], BetOutcome{
+					Outcome:   outcome,
+					Time:      event.CommenceTime,
+					HomeTeam:  event.HomeTeam,
+					AwayTeam:  event.AwayTeam,
+					Market:    market.Key,
+					Bookmaker: bookmaker.Key,
+				})
+			}
+		}
+	}
+	return outcomes
+}
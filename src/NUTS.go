@@ -0,0 +1,361 @@
+package src
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Settings of the No-U-Turn sampler, following Hoffman and Gelman (2014)
+// with Stan's defaults
+const (
+	nutsTargetAccept = 0.8    // dual averaging target acceptance rate
+	nutsGamma        = 0.05   // dual averaging shrinkage
+	nutsT0           = 10.0   // dual averaging stabilization
+	nutsKappa        = 0.75   // dual averaging step size decay
+	nutsMaxDepth     = 10     // trees stop after 2^10 leapfrog steps
+	nutsMaxDeltaH    = 1000.0 // energy error beyond which a trajectory diverged
+)
+
+// SamplerStats describes how a gradient-based or adaptive chain behaved
+// after warmup
+type SamplerStats struct {
+	StepSize      float64
+	Divergences   int
+	MeanTreeDepth float64
+	MaxTreeDepth  int
+	DepthLimitHit int           // iterations that stopped at the maximum tree depth
+	Proposal      *mat.SymDense // adaptive Metropolis proposal covariance, in unconstrained space
+}
+
+// nutsState is a point of a trajectory: position, momentum and the gradient
+// and log density at the position
+type nutsState struct {
+	q       []float64
+	p       []float64
+	grad    []float64
+	logProb float64
+}
+
+// nutsTree is the result of building a subtree
+type nutsTree struct {
+	minus, plus nutsState
+	proposal    nutsState
+	n           int  // points of the subtree inside the slice
+	ok          bool // no U-turn and no divergence
+	diverged    bool
+	alpha       float64 // summed acceptance statistics
+	nAlpha      int
+}
+
+// nuts holds the target and adapted settings of one NUTS chain
+type nuts struct {
+	m          *MarkovChain
+	transforms []Transform
+	logTarget  func([]float64) (float64, float64)
+	invMass    []float64 // diagonal inverse mass matrix
+	stepSize   float64
+}
+
+// NoUTurn samples in continuous parameter space with the No-U-Turn sampler.
+// During the first warmup iterations it adapts the step size by dual
+// averaging and estimates a diagonal mass matrix from the draws of a middle
+// window; it returns numsamples draws after warmup and their negative log
+// likelihoods, and records step size, divergences and tree depths in Stats.
+func (m *MarkovChain) NoUTurn(numsamples int, warmup int) ([][]float64, []float64) {
+	transforms, logTarget := m.unconstrainedTarget()
+	dims := len(transforms)
+
+	s := &nuts{m: m, transforms: transforms, logTarget: logTarget, invMass: make([]float64, dims)}
+	for i := range s.invMass {
+		s.invMass[i] = 1
+	}
+
+	current := s.state(unconstrain(transforms, m.initialPoint()), make([]float64, dims))
+	s.stepSize = s.initialStepSize(current)
+
+	// Warmup windows: step size only, then mass matrix and step size, then
+	// step size again with the final mass matrix
+	initBuffer, termBuffer := 75, 50
+	if warmup < 20+initBuffer+termBuffer {
+		initBuffer = warmup * 15 / 100
+		termBuffer = warmup / 10
+	}
+	windowEnd := warmup - termBuffer
+	// Without a term buffer the step size could not be re-tuned after the
+	// mass matrix changes, so very short warmups adapt the step size only
+	if termBuffer == 0 {
+		windowEnd = 0
+	}
+
+	var windowDraws [][]float64
+	da := newDualAveraging(s.stepSize)
+
+	samples := make([][]float64, 0, numsamples+1)
+	likelihoods := make([]float64, 0, numsamples+1)
+	_, nll := logTarget(current.q)
+	samples = append(samples, constrain(transforms, current.q))
+	likelihoods = append(likelihoods, nll)
+
+	stats := SamplerStats{}
+	totalDepth := 0
+	for i := 0; i < warmup+numsamples; i++ {
+		next, depth, tree := s.transition(current)
+		current = next
+
+		if i < warmup {
+			s.stepSize = da.update(tree.alpha / math.Max(float64(tree.nAlpha), 1))
+
+			if i >= initBuffer && i < windowEnd {
+				windowDraws = append(windowDraws, append([]float64{}, current.q...))
+			}
+			if i == windowEnd-1 && len(windowDraws) > 1 {
+				s.invMass = regularizedVariance(windowDraws)
+				current = s.state(current.q, current.p)
+				s.stepSize = s.initialStepSize(current)
+				da = newDualAveraging(s.stepSize)
+			}
+			if i == warmup-1 {
+				s.stepSize = da.final()
+			}
+			continue
+		}
+
+		if tree.diverged {
+			stats.Divergences++
+		}
+		totalDepth += depth
+		stats.MaxTreeDepth = max(stats.MaxTreeDepth, depth)
+		if depth >= nutsMaxDepth {
+			stats.DepthLimitHit++
+		}
+
+		_, nll := logTarget(current.q)
+		samples = append(samples, constrain(transforms, current.q))
+		likelihoods = append(likelihoods, nll)
+	}
+	if numsamples > 0 {
+		stats.MeanTreeDepth = float64(totalDepth) / float64(numsamples)
+	}
+	stats.StepSize = s.stepSize
+	m.Stats = stats
+
+	return samples, likelihoods
+}
+
+// transition draws a momentum and builds a trajectory from current until it
+// turns back on itself, returning the next state, the tree depth reached and
+// the acceptance statistics and divergence accumulated over every subtree,
+// which drive adaptation
+func (s *nuts) transition(current nutsState) (nutsState, int, nutsTree) {
+	rng := s.m.rng()
+	p := make([]float64, len(current.q))
+	for i := range p {
+		p[i] = rng.NormFloat64() / math.Sqrt(s.invMass[i])
+	}
+	start := current
+	start.p = p
+	joint0 := s.joint(start)
+	logU := joint0 + math.Log(rng.Float64())
+
+	minus, plus := start, start
+	proposal := current
+	n := 1
+	depth := 0
+	var total nutsTree
+	for depth < nutsMaxDepth {
+		direction := 1.0
+		if rng.Float64() < 0.5 {
+			direction = -1
+		}
+
+		var tree nutsTree
+		if direction < 0 {
+			tree = s.buildTree(minus, logU, direction, depth, joint0)
+			minus = tree.minus
+		} else {
+			tree = s.buildTree(plus, logU, direction, depth, joint0)
+			plus = tree.plus
+		}
+		total.alpha += tree.alpha
+		total.nAlpha += tree.nAlpha
+		total.diverged = total.diverged || tree.diverged
+
+		if tree.ok && rng.Float64() < float64(tree.n)/float64(n) {
+			proposal = tree.proposal
+		}
+		n += tree.n
+		depth++
+		if !tree.ok || s.uTurn(minus, plus) {
+			break
+		}
+	}
+	return proposal, depth, total
+}
+
+// buildTree doubles the trajectory 2^depth leapfrog steps in direction
+func (s *nuts) buildTree(from nutsState, logU float64, direction float64, depth int, joint0 float64) nutsTree {
+	if depth == 0 {
+		next := s.leapfrog(from, direction*s.stepSize)
+		joint := s.joint(next)
+		tree := nutsTree{minus: next, plus: next, proposal: next, nAlpha: 1}
+		if logU <= joint {
+			tree.n = 1
+		}
+		tree.ok = logU < nutsMaxDeltaH+joint
+		tree.diverged = !tree.ok
+		if !math.IsNaN(joint) {
+			tree.alpha = math.Min(1, math.Exp(joint-joint0))
+		}
+		return tree
+	}
+
+	tree := s.buildTree(from, logU, direction, depth-1, joint0)
+	if !tree.ok {
+		return tree
+	}
+	var other nutsTree
+	if direction < 0 {
+		other = s.buildTree(tree.minus, logU, direction, depth-1, joint0)
+		tree.minus = other.minus
+	} else {
+		other = s.buildTree(tree.plus, logU, direction, depth-1, joint0)
+		tree.plus = other.plus
+	}
+	if other.n > 0 && s.m.rng().Float64() < float64(other.n)/float64(tree.n+other.n) {
+		tree.proposal = other.proposal
+	}
+	tree.alpha += other.alpha
+	tree.nAlpha += other.nAlpha
+	tree.n += other.n
+	tree.diverged = tree.diverged || other.diverged
+	tree.ok = other.ok && !s.uTurn(tree.minus, tree.plus)
+	return tree
+}
+
+// uTurn reports whether the ends of a trajectory have started moving
+// towards each other
+func (s *nuts) uTurn(minus nutsState, plus nutsState) bool {
+	forward, backward := 0.0, 0.0
+	for i := range minus.q {
+		dq := plus.q[i] - minus.q[i]
+		forward += dq * s.invMass[i] * plus.p[i]
+		backward += dq * s.invMass[i] * minus.p[i]
+	}
+	return forward < 0 || backward < 0
+}
+
+// leapfrog takes one step of Hamiltonian dynamics of size eps
+func (s *nuts) leapfrog(from nutsState, eps float64) nutsState {
+	p := make([]float64, len(from.p))
+	q := make([]float64, len(from.q))
+	for i := range p {
+		p[i] = from.p[i] + eps/2*from.grad[i]
+	}
+	for i := range q {
+		q[i] = from.q[i] + eps*s.invMass[i]*p[i]
+	}
+	next := s.state(q, p)
+	for i := range next.p {
+		next.p[i] += eps / 2 * next.grad[i]
+	}
+	return next
+}
+
+// state evaluates the log density and its gradient at q
+func (s *nuts) state(q []float64, p []float64) nutsState {
+	logProb, _ := s.logTarget(q)
+	grad := s.m.unconstrainedGradient(s.transforms, s.logTarget, q)
+	return nutsState{q: q, p: append([]float64{}, p...), grad: grad, logProb: logProb}
+}
+
+// joint is the log density of position and momentum, minus the Hamiltonian
+func (s *nuts) joint(st nutsState) float64 {
+	kinetic := 0.0
+	for i, p := range st.p {
+		kinetic += p * p * s.invMass[i]
+	}
+	return st.logProb - kinetic/2
+}
+
+// initialStepSize doubles or halves a step size of 1 until one leapfrog
+// step has an acceptance probability crossing one half
+func (s *nuts) initialStepSize(from nutsState) float64 {
+	rng := s.m.rng()
+	eps := 1.0
+	p := make([]float64, len(from.q))
+	for i := range p {
+		p[i] = rng.NormFloat64() / math.Sqrt(s.invMass[i])
+	}
+	start := from
+	start.p = p
+	joint0 := s.joint(start)
+
+	logAccept := s.joint(s.leapfrog(start, eps)) - joint0
+	direction := 1.0
+	if !(logAccept > math.Log(0.5)) {
+		direction = -1
+	}
+	for i := 0; i < 50; i++ {
+		logAccept = s.joint(s.leapfrog(start, eps)) - joint0
+		if math.IsNaN(logAccept) {
+			logAccept = math.Inf(-1)
+		}
+		if direction > 0 && !(logAccept > math.Log(0.5)) || direction < 0 && logAccept > math.Log(0.5) {
+			break
+		}
+		eps *= math.Pow(2, direction)
+	}
+	return eps
+}
+
+// dualAveraging tunes the step size towards the target acceptance rate
+type dualAveraging struct {
+	mu         float64
+	hBar       float64
+	logStep    float64
+	logStepBar float64
+	iteration  float64
+}
+
+func newDualAveraging(stepSize float64) *dualAveraging {
+	return &dualAveraging{mu: math.Log(10 * stepSize), logStep: math.Log(stepSize)}
+}
+
+// update records the acceptance statistic of one iteration and returns the
+// next step size
+func (d *dualAveraging) update(accept float64) float64 {
+	d.iteration++
+	w := 1 / (d.iteration + nutsT0)
+	d.hBar = (1-w)*d.hBar + w*(nutsTargetAccept-accept)
+	d.logStep = d.mu - math.Sqrt(d.iteration)/nutsGamma*d.hBar
+	decay := math.Pow(d.iteration, -nutsKappa)
+	d.logStepBar = decay*d.logStep + (1-decay)*d.logStepBar
+	return math.Exp(d.logStep)
+}
+
+// final is the averaged step size used after warmup
+func (d *dualAveraging) final() float64 {
+	return math.Exp(d.logStepBar)
+}
+
+// regularizedVariance estimates the diagonal inverse mass matrix from
+// warmup draws, shrunk towards a small constant as Stan does
+func regularizedVariance(draws [][]float64) []float64 {
+	n := float64(len(draws))
+	dims := len(draws[0])
+	variance := make([]float64, dims)
+	for j := 0; j < dims; j++ {
+		mean := 0.0
+		for _, d := range draws {
+			mean += d[j]
+		}
+		mean /= n
+		for _, d := range draws {
+			variance[j] += (d[j] - mean) * (d[j] - mean)
+		}
+		variance[j] /= n - 1
+		variance[j] = n/(n+5)*variance[j] + 1e-3*5/(n+5)
+	}
+	return variance
+}
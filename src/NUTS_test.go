@@ -0,0 +1,83 @@
+package src
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// poissonChain samples the rate of Poisson counts under a Gamma prior, whose
+// posterior is Gamma(alpha + sum, beta + n)
+func poissonChain(data []float64, alpha float64, beta float64, seed uint64) MarkovChain {
+	likelihood := Likelihood{
+		Params:             []float64{1},
+		DistributionParams: DistributionParams{Dist: "Poisson", Params: map[string]float64{}},
+		InputData:          *mat.NewDense(1, 1, []float64{0}),
+		OutputData:         *mat.NewVecDense(len(data), data),
+		Link:               func(point []float64, _ []float64) []float64 { return []float64{point[0]} },
+		LinkJacobian:       func(_ []float64, _ []float64) [][]float64 { return [][]float64{{1}} },
+	}
+	return MarkovChain{
+		Distributions: []DistributionParams{{Dist: "Gamma", Params: map[string]float64{"Alpha": alpha, "Beta": beta}}},
+		Likelihood:    likelihood,
+		Sampler:       "NUTS",
+		Rand:          NewRand(seed),
+	}
+}
+
+func TestNoUTurnMatchesConjugatePosterior(t *testing.T) {
+	data := []float64{3, 5, 4, 6, 5, 7, 4, 5, 2, 6}
+	alpha, beta := 2.0, 0.5
+	postAlpha, postBeta := alpha+Sum(data), beta+float64(len(data))
+	wantMean, wantSD := postAlpha/postBeta, math.Sqrt(postAlpha)/postBeta
+
+	m := poissonChain(data, alpha, beta, 7)
+	samples, _ := m.NoUTurn(4000, 1000)
+
+	mean, sq := 0.0, 0.0
+	for _, s := range samples {
+		mean += s[0]
+		sq += s[0] * s[0]
+	}
+	mean /= float64(len(samples))
+	sd := math.Sqrt(sq/float64(len(samples)) - mean*mean)
+
+	if math.Abs(mean-wantMean) > 0.05*wantMean {
+		t.Errorf("posterior mean = %.3f, want %.3f", mean, wantMean)
+	}
+	if math.Abs(sd-wantSD) > 0.1*wantSD {
+		t.Errorf("posterior sd = %.3f, want %.3f", sd, wantSD)
+	}
+	if m.Stats.Divergences > 0 {
+		t.Errorf("%d divergences on a smooth posterior", m.Stats.Divergences)
+	}
+	if m.Stats.MaxTreeDepth >= nutsMaxDepth {
+		t.Errorf("trees reached the depth limit on a one-dimensional posterior")
+	}
+}
+
+func TestNoUTurnAdaptsOnShortWarmups(t *testing.T) {
+	data := []float64{3, 5, 4, 6, 5}
+	for _, warmup := range []int{5, 9, 20} {
+		m := poissonChain(data, 2, 0.5, 3)
+		m.NoUTurn(50, warmup)
+		// Losing the dual averaging state leaves the step size at exactly 1
+		if m.Stats.StepSize == 1 || !(m.Stats.StepSize > 0) {
+			t.Errorf("warmup %d: step size %v was not adapted", warmup, m.Stats.StepSize)
+		}
+	}
+}
+
+func TestDualAveragingReachesTarget(t *testing.T) {
+	// Acceptance falling with the step size settles where it meets the target
+	da := newDualAveraging(1)
+	step := 1.0
+	for i := 0; i < 2000; i++ {
+		step = da.update(math.Exp(-step))
+	}
+	want := -math.Log(nutsTargetAccept)
+	if got := da.final(); math.Abs(got-want) > 0.05*want {
+		t.Errorf("adapted step size = %.4f, want %.4f", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OddsApi serves historical events and event odds from the-odds-api.com.
+// Sport keys and markets come from the sport definitions.
+type OddsApi struct {
+	Key     string
+	BaseURL string
+	Regions string
+}
+
+func NewOddsApi() *OddsApi {
+	return &OddsApi{
+		BaseURL: "https://api.the-odds-api.com/v4",
+		Regions: "us",
+	}
+}
+
+func (o *OddsApi) Name() string {
+	return "the-odds-api"
+}
+
+// Configure sets the api key, base URL and regions
+func (o *OddsApi) Configure(cfg ProviderConfig) error {
+	if cfg.Key != "" {
+		o.Key = cfg.Key
+	}
+	if cfg.BaseURL != "" {
+		o.BaseURL = cfg.BaseURL
+	}
+	if regions, ok := cfg.Options["regions"]; ok {
+		o.Regions = regions
+	}
+	return nil
+}
+
+func (o *OddsApi) Teams(sport string, args map[string]string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (o *OddsApi) Players(sport string, args map[string]string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (o *OddsApi) PlayerGameStats(sport string, args map[string]string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (o *OddsApi) Games(sport string, args map[string]string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (o *OddsApi) OddsEvents(sport string, date string) (string, error) {
+	key, err := o.sportKey(sport)
+	if err != nil {
+		return "", err
+	}
+
+	url := o.BaseURL + "/historical/sports/" + key + "/events" + encodeQuery(map[string]string{
+		"apiKey": o.Key,
+		"date":   date,
+	})
+
+	return httpGet(o.Name(), url, nil)
+}
+
+func (o *OddsApi) EventOdds(sport string, date string, eventID string) (string, error) {
+	key, err := o.sportKey(sport)
+	if err != nil {
+		return "", err
+	}
+	def, err := GetSport(sport)
+	if err != nil {
+		return "", err
+	}
+
+	url := o.BaseURL + "/historical/sports/" + key + "/events/" + eventID + "/odds" + encodeQuery(map[string]string{
+		"apiKey":  o.Key,
+		"date":    date,
+		"regions": o.Regions,
+		"markets": strings.Join(def.OddsMarkets(), ","),
+	})
+
+	return httpGet(o.Name(), url, nil)
+}
+
+func (o *OddsApi) sportKey(sport string) (string, error) {
+	if err := requireKey(o.Name(), o.Key); err != nil {
+		return "", err
+	}
+	def, err := GetSport(sport)
+	if err != nil || def.OddsKey == "" {
+		return "", fmt.Errorf("the-odds-api: unsupported sport %q", sport)
+	}
+	return def.OddsKey, nil
+}
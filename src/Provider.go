@@ -0,0 +1,54 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNotSupported is returned by a Provider for endpoints it does not serve
+var ErrNotSupported = errors.New("endpoint not supported by provider")
+
+// Provider is a source of sports data and/or odds. Every method returns the
+// raw response body so callers can persist it exactly as received.
+type Provider interface {
+	Name() string
+	Teams(sport string, args map[string]string) (string, error)
+	Players(sport string, args map[string]string) (string, error)
+	PlayerGameStats(sport string, args map[string]string) (string, error)
+	Games(sport string, args map[string]string) (string, error)
+	OddsEvents(sport string, date string) (string, error)
+	EventOdds(sport string, date string, eventID string) (string, error)
+}
+
+var providers = map[string]Provider{}
+
+// RegisterProvider makes a provider selectable by name, replacing any
+// provider previously registered under the same name
+func RegisterProvider(p Provider) {
+	providers[p.Name()] = p
+}
+
+// GetProvider looks up a registered provider by name
+func GetProvider(name string) (Provider, error) {
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (available: %v)", name, ProviderNames())
+	}
+	return p, nil
+}
+
+// ProviderNames returns the names of all registered providers, sorted
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterProvider(NewApiSports())
+	RegisterProvider(NewOddsApi())
+}
@@ -0,0 +1,37 @@
+package src
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strconv"
+
+	"golang.org/x/exp/rand"
+)
+
+// Seed is the root of every random stream a run draws from. It is set from
+// the --seed flag; runs with the same seed produce the same samples.
+var Seed uint64
+
+// DeriveSeed mixes labels such as a player and metric into seed, giving each
+// its own stream that does not depend on the order they are processed in
+func DeriveSeed(seed uint64, labels ...string) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], seed)
+	h.Write(buf[:])
+	for _, label := range labels {
+		h.Write([]byte(label))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// ChainSeed is the seed of one chain of a posterior seeded with seed
+func ChainSeed(seed uint64, chain int) uint64 {
+	return DeriveSeed(seed, "chain", strconv.Itoa(chain))
+}
+
+// NewRand returns a generator seeded with seed
+func NewRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
@@ -0,0 +1,103 @@
+package src
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ScheduleDates returns the sorted, de-duplicated YYYY-MM-DD dates of every
+// game in the stored schedules of a season, optionally restricted to
+// [from, to] (either bound may be empty)
+func ScheduleDates(store Store, sport string, season string, from string, to string) ([]string, error) {
+	games, err := store.SeasonSchedule(sport, season)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, game := range games {
+		if len(game.Start) < 10 {
+			continue
+		}
+		date := game.Start[:10]
+		if (from != "" && date < from) || (to != "" && date > to) {
+			continue
+		}
+		seen[date] = true
+	}
+
+	dates := make([]string, 0, len(seen))
+	for date := range seen {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// DateRange lists every YYYY-MM-DD date from from to to inclusive
+func DateRange(from string, to string) ([]string, error) {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", from)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", to)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("range end %s is before start %s", to, from)
+	}
+
+	var dates []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+	return dates, nil
+}
+
+// FetchProgress records which dates of a ranged fetch have completed so an
+// interrupted run can resume
+type FetchProgress struct {
+	Completed map[string]bool `json:"completed"`
+
+	path string
+}
+
+// LoadFetchProgress reads the progress file for a sport, starting empty if
+// it does not exist
+func LoadFetchProgress(sport string, name string) (*FetchProgress, error) {
+	progress := &FetchProgress{
+		Completed: map[string]bool{},
+		path:      filepath.Join(DataDir, sport, name+".progress.json"),
+	}
+	raw, err := LoadRawResponse(progress.path)
+	if err != nil {
+		return nil, err
+	}
+	if completed, ok := raw["completed"].(map[string]interface{}); ok {
+		for date, done := range completed {
+			if b, ok := done.(bool); ok && b {
+				progress.Completed[date] = true
+			}
+		}
+	}
+	return progress, nil
+}
+
+// MarkDone records a date as completed and persists the progress file
+func (p *FetchProgress) MarkDone(date string) error {
+	p.Completed[date] = true
+	return SaveToFile(p, filepath.Dir(p.path), filepath.Base(p.path))
+}
+
+// Reset forgets all completed dates
+func (p *FetchProgress) Reset() error {
+	p.Completed = map[string]bool{}
+	if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %v", p.path, err)
+	}
+	return nil
+}
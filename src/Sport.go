@@ -0,0 +1,267 @@
+package src
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sport describes everything the pipeline needs to know about one sport:
+// where its data lives, how provider stat fields map to canonical metrics
+// and which odds markets settle on which metrics
+type Sport struct {
+	Name      string          `yaml:"name"`
+	ApiSports ApiSportsLayout `yaml:"api_sports"`
+	OddsKey   string          `yaml:"odds_key"`
+
+	// Metrics are the canonical metrics modelled, in the order bayes fits them
+	Metrics []string `yaml:"metrics"`
+	// StatFields maps a provider stat field to a canonical metric. Flat
+	// layouts use a dotted path into each entry, grouped layouts use
+	// "group/statistic".
+	StatFields map[string]string `yaml:"stat_fields"`
+
+	Markets []PlayerMarket `yaml:"markets"`
+	// OtherMarkets are fetched with the odds but not priced
+	OtherMarkets []string `yaml:"other_markets"`
+
+	Teams     []CanonicalTeam `yaml:"teams"`
+	teamIndex map[string]int
+}
+
+// ApiSportsLayout describes a sport's api-sports endpoints and response shapes
+type ApiSportsLayout struct {
+	BaseURL string `yaml:"base_url"`
+	// StatsPath serves per-game player lines
+	StatsPath string `yaml:"stats_path"`
+	// ScheduleFormat is the shape of /games entries: nba, nfl or v1
+	ScheduleFormat string `yaml:"schedule_format"`
+	// StatsFormat is flat (one entry per player per game) or grouped (one
+	// entry per team per game with player lists per stat group)
+	StatsFormat string `yaml:"stats_format"`
+	// PerGameStats is set when player lines can only be fetched one game at a time
+	PerGameStats bool `yaml:"per_game_stats"`
+	// GameStatsParams is the query for one game's player lines; {game} and
+	// {team} are replaced by the ids
+	GameStatsParams  map[string]string `yaml:"game_stats_params"`
+	FinishedStatuses []string          `yaml:"finished_statuses"`
+}
+
+// PlayerMarket ties an odds market to the sum of predicted metrics it settles on
+type PlayerMarket struct {
+	Key  string `yaml:"key"`
+	Type string `yaml:"type"`
+	// Expr is the metric expression, e.g. "rushing_yards + receiving_yards"
+	Expr string `yaml:"expr"`
+	// Point is the line for yes/no markets that carry none, e.g. 0.5 for
+	// an anytime touchdown
+	Point float64 `yaml:"point"`
+
+	Metrics []string `yaml:"-"`
+}
+
+//go:embed sports/*.yaml
+var builtinSports embed.FS
+
+var (
+	sportsMu sync.RWMutex
+	sports   = map[string]*Sport{}
+)
+
+func init() {
+	files, err := builtinSports.ReadDir("sports")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		raw, err := builtinSports.ReadFile("sports/" + f.Name())
+		if err != nil {
+			panic(err)
+		}
+		if err := registerSportYAML(raw); err != nil {
+			panic(fmt.Errorf("built-in sport %s: %v", f.Name(), err))
+		}
+	}
+}
+
+// RegisterSport validates s and makes it available under s.Name, replacing
+// any earlier definition
+func RegisterSport(s *Sport) error {
+	if err := s.compile(); err != nil {
+		return fmt.Errorf("sport %q: %v", s.Name, err)
+	}
+	sportsMu.Lock()
+	defer sportsMu.Unlock()
+	sports[s.Name] = s
+	return nil
+}
+
+// LoadSportFile registers the sport definition in the YAML file at path
+func LoadSportFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read sport definition %s: %v", path, err)
+	}
+	if err := registerSportYAML(raw); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+func registerSportYAML(raw []byte) error {
+	s := &Sport{}
+	if err := yaml.Unmarshal(raw, s); err != nil {
+		return fmt.Errorf("failed to parse sport definition: %v", err)
+	}
+	return RegisterSport(s)
+}
+
+// GetSport returns the registered definition for name
+func GetSport(name string) (*Sport, error) {
+	sportsMu.RLock()
+	defer sportsMu.RUnlock()
+	s, ok := sports[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sport %q (registered: %s)", name, strings.Join(sportNames(), ", "))
+	}
+	return s, nil
+}
+
+// SportNames lists the registered sports in sorted order
+func SportNames() []string {
+	sportsMu.RLock()
+	defer sportsMu.RUnlock()
+	return sportNames()
+}
+
+func sportNames() []string {
+	names := make([]string, 0, len(sports))
+	for name := range sports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SportMetrics lists the canonical metrics modelled for a sport, in the
+// order the bayes command fits them. Unknown sports have none.
+func SportMetrics(sport string) []string {
+	s, err := GetSport(sport)
+	if err != nil {
+		return nil
+	}
+	return s.Metrics
+}
+
+// SportFromPath returns the first registered sport named by a component of
+// path, e.g. nba for data/nba/2024/2024-11-01
+func SportFromPath(path string) (string, error) {
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(path)), "/") {
+		if _, err := GetSport(part); err == nil {
+			return part, nil
+		}
+	}
+	return "", fmt.Errorf("no registered sport in path %s", path)
+}
+
+// OddsMarkets lists every market key fetched with the odds
+func (s *Sport) OddsMarkets() []string {
+	keys := make([]string, 0, len(s.Markets)+len(s.OtherMarkets))
+	for _, m := range s.Markets {
+		keys = append(keys, m.Key)
+	}
+	return append(keys, s.OtherMarkets...)
+}
+
+// Finished reports whether a schedule status means the game is over
+func (s *Sport) Finished(status string) bool {
+	for _, f := range s.ApiSports.FinishedStatuses {
+		if f == status {
+			return true
+		}
+	}
+	return false
+}
+
+// GameStatsParams builds the request for one game's player lines
+func (s *Sport) GameStatsParams(gameID int, teamID int) map[string]string {
+	params := make(map[string]string, len(s.ApiSports.GameStatsParams))
+	for key, value := range s.ApiSports.GameStatsParams {
+		value = strings.ReplaceAll(value, "{game}", strconv.Itoa(gameID))
+		params[key] = strings.ReplaceAll(value, "{team}", strconv.Itoa(teamID))
+	}
+	return params
+}
+
+// compile checks the definition and parses market expressions
+func (s *Sport) compile() error {
+	if s.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if len(s.Metrics) == 0 {
+		return fmt.Errorf("no metrics")
+	}
+	if _, ok := scheduleFormats[s.ApiSports.ScheduleFormat]; !ok {
+		return fmt.Errorf("unknown schedule_format %q", s.ApiSports.ScheduleFormat)
+	}
+	if _, ok := statsFormats[s.ApiSports.StatsFormat]; !ok {
+		return fmt.Errorf("unknown stats_format %q", s.ApiSports.StatsFormat)
+	}
+
+	if err := s.indexTeams(); err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(s.Metrics))
+	for _, m := range s.Metrics {
+		known[m] = true
+	}
+	for field, metric := range s.StatFields {
+		if !known[metric] {
+			return fmt.Errorf("stat field %s maps to unknown metric %q", field, metric)
+		}
+	}
+	for i := range s.Markets {
+		m := &s.Markets[i]
+		if m.Key == "" {
+			return fmt.Errorf("market %d has no key", i)
+		}
+		if m.Type == "" {
+			m.Type = strings.TrimPrefix(m.Key, "player_")
+		}
+		m.Metrics = nil
+		for _, term := range strings.Split(m.Expr, "+") {
+			term = strings.TrimSpace(term)
+			if !known[term] {
+				return fmt.Errorf("market %s uses unknown metric %q", m.Key, term)
+			}
+			m.Metrics = append(m.Metrics, term)
+		}
+	}
+	return nil
+}
+
+// Samples combines the player's predicted metrics for the market. ok is
+// false when any metric was not predicted.
+func (m PlayerMarket) Samples(playerStats map[string][]float64) ([]float64, bool) {
+	var combo []float64
+	for i, metric := range m.Metrics {
+		samples, ok := playerStats[metric]
+		if !ok || len(samples) == 0 {
+			return nil, false
+		}
+		if i == 0 {
+			combo = samples
+		} else {
+			combo = CombinationSum(combo, samples)
+		}
+	}
+	return combo, true
+}
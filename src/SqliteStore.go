@@ -0,0 +1,585 @@
+package src
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema holds one row per game, player game line, odds snapshot and
+// outcome, prediction, arbitrage result, bet slip and output manifest. Raw
+// provider responses are kept next to the decoded columns so nothing is lost.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS documents (
+	sport TEXT NOT NULL, season TEXT NOT NULL, name TEXT NOT NULL, file TEXT NOT NULL,
+	body TEXT NOT NULL,
+	PRIMARY KEY (sport, season, name, file)
+);
+CREATE TABLE IF NOT EXISTS games (
+	sport TEXT NOT NULL, season TEXT NOT NULL, name TEXT NOT NULL, game_id INTEGER NOT NULL,
+	start TEXT NOT NULL, finished INTEGER NOT NULL, home_team TEXT NOT NULL, away_team TEXT NOT NULL,
+	PRIMARY KEY (sport, season, name, game_id)
+);
+CREATE TABLE IF NOT EXISTS player_game_stats (
+	sport TEXT NOT NULL, season TEXT NOT NULL, name TEXT NOT NULL, kind TEXT NOT NULL,
+	game_id INTEGER NOT NULL, player_id INTEGER NOT NULL, player TEXT NOT NULL, team_id INTEGER NOT NULL,
+	stats TEXT NOT NULL,
+	PRIMARY KEY (sport, season, name, kind, game_id, player_id, player)
+);
+CREATE TABLE IF NOT EXISTS odds_snapshots (
+	id INTEGER PRIMARY KEY,
+	sport TEXT NOT NULL, date TEXT NOT NULL, event TEXT NOT NULL, label TEXT NOT NULL,
+	timestamp TEXT NOT NULL, is_primary INTEGER NOT NULL,
+	home_team TEXT NOT NULL, away_team TEXT NOT NULL, commence_time TEXT NOT NULL,
+	body TEXT NOT NULL,
+	UNIQUE (sport, date, event, label, timestamp)
+);
+CREATE INDEX IF NOT EXISTS odds_snapshots_teams ON odds_snapshots (home_team, away_team, commence_time);
+CREATE TABLE IF NOT EXISTS odds_outcomes (
+	snapshot_id INTEGER NOT NULL REFERENCES odds_snapshots (id) ON DELETE CASCADE,
+	market TEXT NOT NULL, bookmaker TEXT NOT NULL, name TEXT NOT NULL, description TEXT NOT NULL,
+	player_key TEXT NOT NULL, price REAL NOT NULL, point REAL
+);
+CREATE INDEX IF NOT EXISTS odds_outcomes_player ON odds_outcomes (player_key, market);
+CREATE INDEX IF NOT EXISTS odds_outcomes_snapshot ON odds_outcomes (snapshot_id);
+CREATE TABLE IF NOT EXISTS predictions (
+	sport TEXT NOT NULL, season TEXT NOT NULL, name TEXT NOT NULL, player TEXT NOT NULL,
+	metric TEXT NOT NULL, samples TEXT NOT NULL,
+	PRIMARY KEY (sport, season, name, player, metric)
+);
+CREATE TABLE IF NOT EXISTS arbitrage_results (
+	scope TEXT NOT NULL, idx INTEGER NOT NULL,
+	player TEXT NOT NULL, market TEXT NOT NULL, differential REAL NOT NULL,
+	result TEXT NOT NULL,
+	PRIMARY KEY (scope, idx)
+);
+CREATE TABLE IF NOT EXISTS bets (
+	idx INTEGER PRIMARY KEY,
+	slip TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS manifests (
+	output TEXT PRIMARY KEY,
+	manifest TEXT NOT NULL
+);
+`
+
+// sqliteTime is how snapshot timestamps are stored, so they sort as text
+const sqliteTime = "2006-01-02T15:04:05Z"
+
+// SQLiteStore keeps pipeline state in a single SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens or creates the database at path
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directories for %s: %v", path, err)
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	// fetchdata writes from several workers; sqlite serialises writers anyway
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema in %s: %v", path, err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// inTx runs f in a transaction, committing when it succeeds
+func (s *SQLiteStore) inTx(f func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveDocument(key DataKey, name string, raw string) error {
+	_, err := s.db.Exec(`INSERT INTO documents (sport, season, name, file, body) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT DO UPDATE SET body = excluded.body`, key.Sport, key.Season, key.Name, name, raw)
+	if err != nil {
+		return fmt.Errorf("failed to save %s of %s: %v", name, key, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveGames(key DataKey, raw string) error {
+	games, err := DecodeSchedule(key.Sport, raw)
+	if err != nil {
+		return err
+	}
+	return s.inTx(func(tx *sql.Tx) error {
+		for _, g := range games {
+			_, err := tx.Exec(`INSERT INTO games (sport, season, name, game_id, start, finished, home_team, away_team)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT DO UPDATE SET start = excluded.start, finished = excluded.finished,
+					home_team = excluded.home_team, away_team = excluded.away_team`,
+				key.Sport, key.Season, key.Name, g.ID, g.Start, g.Finished, g.HomeTeam, g.AwayTeam)
+			if err != nil {
+				return fmt.Errorf("failed to save game %d of %s: %v", g.ID, key, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) LoadSchedule(key DataKey) ([]ScheduledGame, error) {
+	return s.queryGames(`WHERE sport = ? AND season = ? AND name = ?`, key.Sport, key.Season, key.Name)
+}
+
+func (s *SQLiteStore) SeasonSchedule(sport string, season string) ([]ScheduledGame, error) {
+	return s.queryGames(`WHERE sport = ? AND season = ?`, sport, season)
+}
+
+func (s *SQLiteStore) queryGames(where string, args ...interface{}) ([]ScheduledGame, error) {
+	rows, err := s.db.Query(`SELECT game_id, start, finished, home_team, away_team FROM games `+where+` ORDER BY start, game_id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query games: %v", err)
+	}
+	defer rows.Close()
+	var games []ScheduledGame
+	for rows.Next() {
+		var g ScheduledGame
+		if err := rows.Scan(&g.ID, &g.Start, &g.Finished, &g.HomeTeam, &g.AwayTeam); err != nil {
+			return nil, fmt.Errorf("failed to read game: %v", err)
+		}
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+func (s *SQLiteStore) SaveGameStats(key DataKey, raw string) error {
+	lines, err := DecodeGameLines(key.Sport, raw)
+	if err != nil {
+		return err
+	}
+	return s.inTx(func(tx *sql.Tx) error {
+		for _, l := range lines {
+			stats, err := json.Marshal(l.Stats)
+			if err != nil {
+				return fmt.Errorf("failed to encode stats of %s: %v", l.Player, err)
+			}
+			// Upserting keeps the rowid, so lines stay in the order first seen
+			_, err = tx.Exec(`INSERT INTO player_game_stats (sport, season, name, kind, game_id, player_id, player, team_id, stats)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT DO UPDATE SET team_id = excluded.team_id, stats = excluded.stats`,
+				key.Sport, key.Season, key.Name, key.Kind, l.GameID, l.PlayerID, l.Player, l.TeamID, string(stats))
+			if err != nil {
+				return fmt.Errorf("failed to save stats of %s in game %d: %v", l.Player, l.GameID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) LoadGameLines(key DataKey) ([]GameLine, error) {
+	rows, err := s.db.Query(`SELECT player, player_id, team_id, game_id, stats FROM player_game_stats
+		WHERE sport = ? AND season = ? AND name = ? AND kind = ? ORDER BY rowid`, key.Sport, key.Season, key.Name, key.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats of %s: %v", key, err)
+	}
+	defer rows.Close()
+	var lines []GameLine
+	for rows.Next() {
+		var l GameLine
+		var stats string
+		if err := rows.Scan(&l.Player, &l.PlayerID, &l.TeamID, &l.GameID, &stats); err != nil {
+			return nil, fmt.Errorf("failed to read stats of %s: %v", key, err)
+		}
+		if err := json.Unmarshal([]byte(stats), &l.Stats); err != nil {
+			return nil, fmt.Errorf("failed to decode stats of %s: %v", l.Player, err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+func (s *SQLiteStore) HasGameLines(key DataKey) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM player_game_stats WHERE sport = ? AND season = ? AND name = ? AND kind = ?`,
+		key.Sport, key.Season, key.Name, key.Kind).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to query stats of %s: %v", key, err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) StatKeys() ([]DataKey, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT sport, season, name, kind FROM player_game_stats ORDER BY sport, season, name, kind DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stats: %v", err)
+	}
+	defer rows.Close()
+	var keys []DataKey
+	for rows.Next() {
+		var k DataKey
+		if err := rows.Scan(&k.Sport, &k.Season, &k.Name, &k.Kind); err != nil {
+			return nil, fmt.Errorf("failed to list stats: %v", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLiteStore) SaveOdds(key EventKey, label string, timestamp time.Time, raw string, primary bool) error {
+	snapshot, err := DecodeEventOdds(raw)
+	if err != nil {
+		return err
+	}
+	event := snapshot.Data
+	stamp := timestamp.UTC().Format(sqliteTime)
+
+	return s.inTx(func(tx *sql.Tx) error {
+		if primary {
+			if _, err := tx.Exec(`UPDATE odds_snapshots SET is_primary = 0 WHERE sport = ? AND date = ? AND event = ?`,
+				key.Sport, key.Date, key.Event); err != nil {
+				return fmt.Errorf("failed to update odds of %s: %v", key.Event, err)
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM odds_snapshots WHERE sport = ? AND date = ? AND event = ? AND label = ? AND timestamp = ?`,
+			key.Sport, key.Date, key.Event, label, stamp); err != nil {
+			return fmt.Errorf("failed to replace odds of %s: %v", key.Event, err)
+		}
+		res, err := tx.Exec(`INSERT INTO odds_snapshots (sport, date, event, label, timestamp, is_primary, home_team, away_team, commence_time, body)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			key.Sport, key.Date, key.Event, label, stamp, primary, event.HomeTeam, event.AwayTeam, event.CommenceTime, raw)
+		if err != nil {
+			return fmt.Errorf("failed to save odds of %s: %v", key.Event, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to save odds of %s: %v", key.Event, err)
+		}
+
+		for market, outcomes := range FlattenOutcomes(event) {
+			for _, o := range outcomes {
+				_, err := tx.Exec(`INSERT INTO odds_outcomes (snapshot_id, market, bookmaker, name, description, player_key, price, point)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+					id, market, o.Bookmaker, o.Name, o.Description, PlayerKey(o.Description), o.Price, o.Point)
+				if err != nil {
+					return fmt.Errorf("failed to save %s outcome of %s: %v", market, key.Event, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) HasOdds(key EventKey, label string) (bool, error) {
+	query := `SELECT COUNT(*) FROM odds_snapshots WHERE sport = ? AND date = ? AND event = ? AND `
+	args := []interface{}{key.Sport, key.Date, key.Event}
+	if label == "" {
+		query += `is_primary = 1`
+	} else {
+		query += `label = ?`
+		args = append(args, label)
+	}
+	var n int
+	if err := s.db.QueryRow(query, args...).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to query odds of %s: %v", key.Event, err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLiteStore) Odds(scope OddsScope) (map[EventKey]EventOdds, error) {
+	rows, err := s.db.Query(`SELECT sport, date, event, body FROM odds_snapshots
+		WHERE is_primary = 1 AND sport = ? AND date LIKE ? AND date LIKE ?`,
+		scope.Sport, scope.Season+"%", scope.Date+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query odds of %s: %v", scope, err)
+	}
+	defer rows.Close()
+
+	data := make(map[EventKey]EventOdds)
+	for rows.Next() {
+		var key EventKey
+		var body string
+		if err := rows.Scan(&key.Sport, &key.Date, &key.Event, &body); err != nil {
+			return nil, fmt.Errorf("failed to read odds of %s: %v", scope, err)
+		}
+		snapshot, err := DecodeEventOdds(body)
+		if err != nil {
+			fmt.Printf("Skipping %s %s: %v\n", key.Date, key.Event, err)
+			continue
+		}
+		data[key] = snapshot.Data
+	}
+	return data, rows.Err()
+}
+
+func (s *SQLiteStore) OddsAt(key EventKey, target time.Time) (OddsSnapshot, bool, error) {
+	found := OddsSnapshot{Key: key}
+	var stamp, body string
+	err := s.db.QueryRow(`SELECT label, timestamp, body FROM odds_snapshots
+		WHERE sport = ? AND date = ? AND event = ? AND timestamp <= ?
+		ORDER BY timestamp DESC LIMIT 1`,
+		key.Sport, key.Date, key.Event, target.UTC().Format(sqliteTime)).Scan(&found.Label, &stamp, &body)
+	if err == sql.ErrNoRows {
+		return found, false, nil
+	}
+	if err != nil {
+		return found, false, fmt.Errorf("failed to query odds of %s: %v", key.Event, err)
+	}
+	if found.Timestamp, err = time.Parse(sqliteTime, stamp); err != nil {
+		return found, false, fmt.Errorf("invalid snapshot time %q: %v", stamp, err)
+	}
+	decoded, err := DecodeEventOdds(body)
+	if err != nil {
+		fmt.Printf("Skipping %s snapshot of %s: %v\n", found.Label, key.Event, err)
+		return found, false, nil
+	}
+	found.Event = decoded.Data
+	return found, true, nil
+}
+
+func (s *SQLiteStore) FindEvent(sport string, homeTeam string, awayTeam string, commenceTime string) (EventKey, bool, error) {
+	key := EventKey{}
+	err := s.db.QueryRow(`SELECT sport, date, event FROM odds_snapshots
+		WHERE home_team = ? AND away_team = ? AND commence_time = ? AND (? = '' OR sport = ?)
+		ORDER BY is_primary DESC LIMIT 1`,
+		homeTeam, awayTeam, commenceTime, sport, sport).Scan(&key.Sport, &key.Date, &key.Event)
+	if err == sql.ErrNoRows {
+		return key, false, nil
+	}
+	if err != nil {
+		return key, false, fmt.Errorf("failed to find event %s @ %s: %v", awayTeam, homeTeam, err)
+	}
+	return key, true, nil
+}
+
+func (s *SQLiteStore) PropOdds(q PropQuery) ([]PropLine, error) {
+	where := []string{"o.description != ''"}
+	var args []interface{}
+	add := func(clause string, value string) {
+		if value != "" {
+			where = append(where, clause)
+			args = append(args, value)
+		}
+	}
+	add("s.sport = ?", q.Sport)
+	add("o.market = ?", q.Market)
+	add("o.bookmaker = ?", q.Bookmaker)
+	add("s.date >= ?", q.From)
+	add("s.date <= ?", q.To)
+	if q.Player != "" {
+		add("o.player_key = ?", PlayerKey(q.Player))
+	}
+	if !q.AllSnapshots {
+		where = append(where, "s.is_primary = 1")
+	}
+
+	rows, err := s.db.Query(`SELECT o.market, o.bookmaker, o.name, o.description, o.price, o.point,
+			s.commence_time, s.home_team, s.away_team, s.timestamp, s.label
+		FROM odds_outcomes o JOIN odds_snapshots s ON s.id = o.snapshot_id
+		WHERE `+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search odds: %v", err)
+	}
+	defer rows.Close()
+
+	var lines []PropLine
+	for rows.Next() {
+		var l PropLine
+		var point sql.NullFloat64
+		if err := rows.Scan(&l.Market, &l.Bookmaker, &l.Name, &l.Description, &l.Price, &point,
+			&l.Time, &l.HomeTeam, &l.AwayTeam, &l.Snapshot, &l.Label); err != nil {
+			return nil, fmt.Errorf("failed to read odds: %v", err)
+		}
+		if point.Valid {
+			l.Point = &point.Float64
+		}
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortPropLines(lines)
+	return lines, nil
+}
+
+func (s *SQLiteStore) SavePredictions(key DataKey, player string, preds map[string][]float64) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		for metric, samples := range preds {
+			encoded, err := json.Marshal(samples)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s predictions of %s: %v", metric, player, err)
+			}
+			_, err = tx.Exec(`INSERT INTO predictions (sport, season, name, player, metric, samples) VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT DO UPDATE SET samples = excluded.samples`,
+				key.Sport, key.Season, key.Name, player, metric, string(encoded))
+			if err != nil {
+				return fmt.Errorf("failed to save %s predictions of %s: %v", metric, player, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) LoadPredictions(key DataKey) (map[string]map[string][]float64, error) {
+	rows, err := s.db.Query(`SELECT player, metric, samples FROM predictions WHERE sport = ? AND season = ? AND name = ?`,
+		key.Sport, key.Season, key.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query predictions of %s: %v", key, err)
+	}
+	defer rows.Close()
+
+	preds := make(map[string]map[string][]float64)
+	for rows.Next() {
+		var player, metric, samples string
+		if err := rows.Scan(&player, &metric, &samples); err != nil {
+			return nil, fmt.Errorf("failed to read predictions of %s: %v", key, err)
+		}
+		var values []float64
+		if err := json.Unmarshal([]byte(samples), &values); err != nil {
+			return nil, fmt.Errorf("failed to decode %s predictions of %s: %v", metric, player, err)
+		}
+		if preds[player] == nil {
+			preds[player] = make(map[string][]float64)
+		}
+		preds[player][metric] = values
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(preds) == 0 {
+		return nil, fmt.Errorf("no predictions stored for %s", key)
+	}
+	return preds, nil
+}
+
+func (s *SQLiteStore) SaveArbitrage(scope OddsScope, results []ArbitrageResult) error {
+	results = sanitizeResults(results)
+	err := s.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM arbitrage_results WHERE scope = ?`, scope.String()); err != nil {
+			return fmt.Errorf("failed to replace arbitrage results of %s: %v", scope, err)
+		}
+		for i, r := range results {
+			encoded, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("failed to encode arbitrage result: %v", err)
+			}
+			_, err = tx.Exec(`INSERT INTO arbitrage_results (scope, idx, player, market, differential, result) VALUES (?, ?, ?, ?, ?, ?)`,
+				scope.String(), i, r.Bet.Description, r.Bet.Market, r.Differential, string(encoded))
+			if err != nil {
+				return fmt.Errorf("failed to save arbitrage result: %v", err)
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		fmt.Printf("Results successfully written to %s arbitrage results\n", scope)
+	}
+	return err
+}
+
+func (s *SQLiteStore) LoadArbitrage() (map[string][]ArbitrageResult, error) {
+	rows, err := s.db.Query(`SELECT scope, result FROM arbitrage_results ORDER BY scope, idx`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query arbitrage results: %v", err)
+	}
+	defer rows.Close()
+
+	arbs := make(map[string][]ArbitrageResult)
+	for rows.Next() {
+		var scope, result string
+		if err := rows.Scan(&scope, &result); err != nil {
+			return nil, fmt.Errorf("failed to read arbitrage results: %v", err)
+		}
+		var r ArbitrageResult
+		if err := json.Unmarshal([]byte(result), &r); err != nil {
+			return nil, fmt.Errorf("failed to decode arbitrage result of %s: %v", scope, err)
+		}
+		arbs[scope] = append(arbs[scope], r)
+	}
+	return arbs, rows.Err()
+}
+
+func (s *SQLiteStore) SaveBets(bets []BetSlip) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM bets`); err != nil {
+			return fmt.Errorf("failed to replace bets: %v", err)
+		}
+		for i, b := range bets {
+			encoded, err := json.Marshal(b)
+			if err != nil {
+				return fmt.Errorf("failed to encode bet: %v", err)
+			}
+			if _, err := tx.Exec(`INSERT INTO bets (idx, slip) VALUES (?, ?)`, i, string(encoded)); err != nil {
+				return fmt.Errorf("failed to save bet: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) LoadBets() ([]BetSlip, error) {
+	rows, err := s.db.Query(`SELECT slip FROM bets ORDER BY idx`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bets: %v", err)
+	}
+	defer rows.Close()
+
+	var bets []BetSlip
+	for rows.Next() {
+		var slip string
+		if err := rows.Scan(&slip); err != nil {
+			return nil, fmt.Errorf("failed to read bets: %v", err)
+		}
+		var b BetSlip
+		if err := json.Unmarshal([]byte(slip), &b); err != nil {
+			return nil, fmt.Errorf("failed to decode bet: %v", err)
+		}
+		bets = append(bets, b)
+	}
+	return bets, rows.Err()
+}
+
+func (s *SQLiteStore) SaveManifest(m Manifest) error {
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest of %s: %v", m.Output, err)
+	}
+	_, err = s.db.Exec(`INSERT INTO manifests (output, manifest) VALUES (?, ?)
+		ON CONFLICT DO UPDATE SET manifest = excluded.manifest`, m.Output, string(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to save manifest of %s: %v", m.Output, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) LoadManifest(ref string) (Manifest, bool, error) {
+	var m Manifest
+	var encoded string
+	err := s.db.QueryRow(`SELECT manifest FROM manifests WHERE output = ?`, ref).Scan(&encoded)
+	if err == sql.ErrNoRows {
+		return m, false, nil
+	}
+	if err != nil {
+		return m, false, fmt.Errorf("failed to query manifest of %s: %v", ref, err)
+	}
+	if err := json.Unmarshal([]byte(encoded), &m); err != nil {
+		return m, false, fmt.Errorf("failed to decode manifest of %s: %v", ref, err)
+	}
+	return m, true, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
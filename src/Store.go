@@ -0,0 +1,214 @@
+package src
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StorageBackend selects where pipeline state lives: json for the data/
+// tree, sqlite for a single database file. It is set from the storage
+// section of the config.
+var StorageBackend = "json"
+
+// StoragePath is the sqlite database file; empty means DataDir/betterbetter.db
+var StoragePath = ""
+
+// DataKey locates the fetched data and predictions of one team or player in
+// a season, e.g. nba/2024/celtics
+type DataKey struct {
+	Sport  string
+	Season string
+	Name   string
+	// Kind is team or player; a directory may hold stats of both
+	Kind string
+}
+
+func (k DataKey) String() string {
+	return k.Sport + "/" + k.Season + "/" + k.Name
+}
+
+// OddsScope selects stored odds: a sport, optionally narrowed to a season
+// directory and a date or date prefix such as 2024-11
+type OddsScope struct {
+	Sport  string
+	Season string
+	Date   string
+}
+
+func (s OddsScope) String() string {
+	return strings.TrimRight(s.Sport+"/"+s.Season+"/"+s.Date, "/")
+}
+
+// matches reports whether a YYYY-MM-DD date falls in the scope
+func (s OddsScope) matches(date string) bool {
+	if s.Season != "" && !strings.HasPrefix(date, s.Season) {
+		return false
+	}
+	return strings.HasPrefix(date, s.Date)
+}
+
+// EventKey locates one game's odds: the game date and the event directory
+// name, e.g. lakers_celtics
+type EventKey struct {
+	Sport string
+	Date  string
+	Event string
+}
+
+// OddsSnapshot is one stored odds snapshot of an event
+type OddsSnapshot struct {
+	Key       EventKey
+	Label     string
+	Timestamp time.Time
+	Event     EventOdds
+}
+
+// PropQuery selects player prop lines across stored odds. Empty fields
+// match everything; From and To are inclusive YYYY-MM-DD game dates.
+type PropQuery struct {
+	Sport     string
+	Player    string
+	Market    string
+	Bookmaker string
+	From      string
+	To        string
+	// AllSnapshots includes every stored snapshot, not only odds.json
+	AllSnapshots bool
+}
+
+// PropLine is one outcome returned by a PropQuery
+type PropLine struct {
+	BetOutcome
+	Snapshot string `json:"snapshot"`
+	Label    string `json:"label"`
+}
+
+// Store persists everything the pipeline fetches and produces
+type Store interface {
+	// SaveDocument keeps a provider response that has no table of its own,
+	// such as team_data.json
+	SaveDocument(key DataKey, name string, raw string) error
+	// SaveGames merges a games response into the key's schedule
+	SaveGames(key DataKey, raw string) error
+	LoadSchedule(key DataKey) ([]ScheduledGame, error)
+	// SeasonSchedule returns the games of every key stored for a season
+	SeasonSchedule(sport string, season string) ([]ScheduledGame, error)
+	// SaveGameStats merges a player statistics response into the key's lines
+	SaveGameStats(key DataKey, raw string) error
+	// LoadGameLines returns the lines stored for the key's kind, oldest first
+	LoadGameLines(key DataKey) ([]GameLine, error)
+	HasGameLines(key DataKey) (bool, error)
+	// StatKeys lists every key with stored game lines
+	StatKeys() ([]DataKey, error)
+
+	// SaveOdds stores an odds response for an event; primary marks the
+	// snapshot arbitrage uses by default
+	SaveOdds(key EventKey, label string, timestamp time.Time, raw string, primary bool) error
+	// HasOdds reports whether a snapshot with label is stored; an empty
+	// label asks for the primary snapshot
+	HasOdds(key EventKey, label string) (bool, error)
+	// Odds returns the primary snapshot of every event in scope
+	Odds(scope OddsScope) (map[EventKey]EventOdds, error)
+	// OddsAt returns the latest snapshot of an event taken at or before target
+	OddsAt(key EventKey, target time.Time) (OddsSnapshot, bool, error)
+	// FindEvent locates the odds of a game from a bet's teams and commence time
+	FindEvent(sport string, homeTeam string, awayTeam string, commenceTime string) (EventKey, bool, error)
+	PropOdds(q PropQuery) ([]PropLine, error)
+
+	SavePredictions(key DataKey, player string, preds map[string][]float64) error
+	LoadPredictions(key DataKey) (map[string]map[string][]float64, error)
+
+	SaveArbitrage(scope OddsScope, results []ArbitrageResult) error
+	// LoadArbitrage returns every stored arbitrage run keyed by its scope
+	LoadArbitrage() (map[string][]ArbitrageResult, error)
+
+	SaveBets(bets []BetSlip) error
+	LoadBets() ([]BetSlip, error)
+
+	// SaveManifest records how the output m.Output was produced
+	SaveManifest(m Manifest) error
+	LoadManifest(ref string) (Manifest, bool, error)
+
+	Close() error
+}
+
+// OpenStore opens the configured storage backend
+func OpenStore() (Store, error) {
+	switch StorageBackend {
+	case "", "json":
+		return &JSONStore{Root: DataDir}, nil
+	case "sqlite":
+		path := StoragePath
+		if path == "" {
+			path = filepath.Join(DataDir, "betterbetter.db")
+		}
+		return OpenSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q: use json or sqlite", StorageBackend)
+	}
+}
+
+// dataParts splits a path under DataDir into its components
+func dataParts(path string) []string {
+	clean := filepath.Clean(path)
+	abs, err := filepath.Abs(clean)
+	root, rootErr := filepath.Abs(DataDir)
+	if err == nil && rootErr == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			clean = rel
+		}
+	}
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(clean), "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// DataKeyFromPath reads a key from a <data>/<sport>/<season>/<name> path,
+// ignoring a trailing preds directory
+func DataKeyFromPath(path string) (DataKey, error) {
+	parts := dataParts(path)
+	if len(parts) > 0 && parts[len(parts)-1] == "preds" {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) != 3 {
+		return DataKey{}, fmt.Errorf("%s is not a <data>/<sport>/<season>/<team> path", path)
+	}
+	return DataKey{Sport: parts[0], Season: parts[1], Name: parts[2], Kind: "team"}, nil
+}
+
+// OddsScopeFromPath reads a scope from a <data>/<sport>[/<season>[/<date>]] path
+func OddsScopeFromPath(path string) (OddsScope, error) {
+	parts := dataParts(path)
+	if len(parts) == 0 || len(parts) > 3 {
+		return OddsScope{}, fmt.Errorf("%s is not a <data>/<sport>/<season>/<date> path", path)
+	}
+	scope := OddsScope{Sport: parts[0]}
+	if len(parts) > 1 {
+		scope.Season = parts[1]
+	}
+	if len(parts) > 2 {
+		scope.Date = parts[2]
+	}
+	return scope, nil
+}
+
+// Dir returns where the JSON tree keeps the key's files
+func (k DataKey) Dir(root string) string {
+	return filepath.Join(root, k.Sport, k.Season, k.Name)
+}
+
+// Dir returns where the JSON tree keeps the scope's files
+func (s OddsScope) Dir(root string) string {
+	return filepath.Join(root, s.Sport, s.Season, s.Date)
+}
+
+// Dir returns where the JSON tree keeps the event's files
+func (k EventKey) Dir(root string) string {
+	return filepath.Join(root, k.Sport, k.Date[:4], k.Date, k.Event)
+}
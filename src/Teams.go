@@ -0,0 +1,110 @@
+package src
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CanonicalTeam is one entry of a sport's canonical team table
+type CanonicalTeam struct {
+	// ID is the canonical abbreviation, e.g. BOS
+	ID       string `yaml:"id"`
+	Name     string `yaml:"name"` // full name as the-odds-api writes it
+	Nickname string `yaml:"nickname"`
+	// Aliases are other abbreviations and spellings providers use
+	Aliases []string `yaml:"aliases"`
+	// ProviderIDs are the team's ids at providers that number teams
+	ProviderIDs map[string]int `yaml:"provider_ids"`
+}
+
+// Slug names the team's directories: the nickname in lower case with
+// underscores, e.g. trail_blazers
+func (t *CanonicalTeam) Slug() string {
+	return strings.ReplaceAll(strings.ToLower(t.Nickname), " ", "_")
+}
+
+// names lists every spelling the team is known by
+func (t *CanonicalTeam) names() []string {
+	names := []string{t.ID, t.Name, t.Nickname, t.Slug()}
+	return append(names, t.Aliases...)
+}
+
+// indexTeams checks the team table and indexes every spelling
+func (s *Sport) indexTeams() error {
+	s.teamIndex = make(map[string]int)
+	for i := range s.Teams {
+		t := &s.Teams[i]
+		if t.ID == "" || t.Name == "" || t.Nickname == "" {
+			return fmt.Errorf("team %d needs an id, name and nickname", i)
+		}
+		for _, name := range t.names() {
+			key := PlayerKey(name)
+			if other, ok := s.teamIndex[key]; ok && other != i {
+				return fmt.Errorf("team name %q is used by both %s and %s", name, s.Teams[other].ID, t.ID)
+			}
+			s.teamIndex[key] = i
+		}
+	}
+	return nil
+}
+
+// Team resolves any abbreviation, full name, nickname, alias or directory
+// slug of a team, ignoring case, accents and underscores
+func (s *Sport) Team(name string) (*CanonicalTeam, bool) {
+	i, ok := s.teamIndex[PlayerKey(name)]
+	if !ok {
+		return nil, false
+	}
+	return &s.Teams[i], true
+}
+
+// TeamByProviderID finds the team a provider numbers id
+func (s *Sport) TeamByProviderID(provider string, id int) (*CanonicalTeam, bool) {
+	for i := range s.Teams {
+		if pid, ok := s.Teams[i].ProviderIDs[provider]; ok && pid == id {
+			return &s.Teams[i], true
+		}
+	}
+	return nil, false
+}
+
+// SameTeam reports whether two spellings resolve to the same team
+func (s *Sport) SameTeam(a string, b string) bool {
+	ta, ok := s.Team(a)
+	if !ok {
+		return PlayerKey(a) == PlayerKey(b)
+	}
+	tb, ok := s.Team(b)
+	return ok && ta.ID == tb.ID
+}
+
+// TeamDir returns the stats directory name for a team given in any spelling,
+// falling back to the name as given
+func (s *Sport) TeamDir(name string) string {
+	if t, ok := s.Team(name); ok {
+		return t.Slug()
+	}
+	return name
+}
+
+// EventDir returns the odds directory name for a game, <away>_<home> by team
+// slug, e.g. lakers_celtics. Teams missing from the table keep their full
+// name with spaces replaced.
+func (s *Sport) EventDir(away string, home string) string {
+	return s.TeamDir(strings.ReplaceAll(away, " ", "_")) + "_" + s.TeamDir(strings.ReplaceAll(home, " ", "_"))
+}
+
+// TeamFromPath returns the team whose slug is a component of path, e.g.
+// celtics for data/nba/2024/celtics/preds
+func (s *Sport) TeamFromPath(path string) (*CanonicalTeam, bool) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		for j := range s.Teams {
+			if s.Teams[j].Slug() == parts[i] {
+				return &s.Teams[j], true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,128 @@
+package src
+
+import "math"
+
+// Transform maps a parameter between its support and the real line, so
+// samplers can move freely in unconstrained space
+type Transform struct {
+	Lower float64 // -Inf when unbounded below
+	Upper float64 // +Inf when unbounded above
+}
+
+// TransformFor returns the transform onto the support of a prior
+func TransformFor(prior DistributionParams) Transform {
+	switch prior.Dist {
+	case "Uniform":
+		return Transform{Lower: prior.Params["Min"], Upper: prior.Params["Max"]}
+	case "Beta":
+		return Transform{Lower: 0, Upper: 1}
+	case "Exponential", "Gamma", "LogNormal", "ChiSquared", "Weibull", "Poisson":
+		return Transform{Lower: 0, Upper: math.Inf(1)}
+	case "Pareto":
+		return Transform{Lower: prior.Params["Xm"], Upper: math.Inf(1)}
+	default:
+		return Transform{Lower: math.Inf(-1), Upper: math.Inf(1)}
+	}
+}
+
+// Constrain maps y from the real line onto the support
+func (t Transform) Constrain(y float64) float64 {
+	switch {
+	case t.bounded():
+		return t.Lower + (t.Upper-t.Lower)*sigmoid(y)
+	case !math.IsInf(t.Lower, -1):
+		return t.Lower + math.Exp(y)
+	case !math.IsInf(t.Upper, 1):
+		return t.Upper - math.Exp(y)
+	default:
+		return y
+	}
+}
+
+// Unconstrain maps x from the support onto the real line
+func (t Transform) Unconstrain(x float64) float64 {
+	switch {
+	case t.bounded():
+		p := (x - t.Lower) / (t.Upper - t.Lower)
+		return math.Log(p) - math.Log1p(-p)
+	case !math.IsInf(t.Lower, -1):
+		return math.Log(x - t.Lower)
+	case !math.IsInf(t.Upper, 1):
+		return math.Log(t.Upper - x)
+	default:
+		return x
+	}
+}
+
+// LogJacobian is log |dx/dy| at y, the density correction for sampling in
+// unconstrained space
+func (t Transform) LogJacobian(y float64) float64 {
+	switch {
+	case t.bounded():
+		// log(sigmoid(y)) + log(1 - sigmoid(y)) = -|y| - 2 log(1 + e^-|y|)
+		return math.Log(t.Upper-t.Lower) - math.Abs(y) - 2*math.Log1p(math.Exp(-math.Abs(y)))
+	case !math.IsInf(t.Lower, -1), !math.IsInf(t.Upper, 1):
+		return y
+	default:
+		return 0
+	}
+}
+
+// DLogJacobian is the derivative of LogJacobian at y
+func (t Transform) DLogJacobian(y float64) float64 {
+	switch {
+	case t.bounded():
+		return 1 - 2*sigmoid(y)
+	case !math.IsInf(t.Lower, -1), !math.IsInf(t.Upper, 1):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// DConstrain is dx/dy at y
+func (t Transform) DConstrain(y float64) float64 {
+	switch {
+	case t.bounded():
+		s := sigmoid(y)
+		return (t.Upper - t.Lower) * s * (1 - s)
+	case !math.IsInf(t.Lower, -1):
+		return math.Exp(y)
+	case !math.IsInf(t.Upper, 1):
+		return -math.Exp(y)
+	default:
+		return 1
+	}
+}
+
+func (t Transform) bounded() bool {
+	return !math.IsInf(t.Lower, -1) && !math.IsInf(t.Upper, 1)
+}
+
+func sigmoid(y float64) float64 {
+	if y >= 0 {
+		return 1 / (1 + math.Exp(-y))
+	}
+	e := math.Exp(y)
+	return e / (1 + e)
+}
+
+// LogPrior is the summed log density of point under priors, one per
+// parameter
+func LogPrior(priors []DistributionParams, point []float64) float64 {
+	sum := 0.0
+	for i, prior := range priors {
+		sum += prior.LogProb(point[i])
+	}
+	return sum
+}
+
+// LogProb is the log density of the distribution at x, -Inf outside its
+// support
+func (d *DistributionParams) LogProb(x float64) float64 {
+	dist, ok := d.CreateDist(nil).(interface{ LogProb(float64) float64 })
+	if !ok {
+		return math.Inf(-1)
+	}
+	return dist.LogProb(x)
+}
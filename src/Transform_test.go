@@ -0,0 +1,73 @@
+package src
+
+import (
+	"math"
+	"testing"
+)
+
+var testTransforms = []struct {
+	name   string
+	t      Transform
+	points []float64 // in the support
+}{
+	{"unbounded", Transform{Lower: math.Inf(-1), Upper: math.Inf(1)}, []float64{-3, 0, 2.5}},
+	{"lower", Transform{Lower: 0, Upper: math.Inf(1)}, []float64{1e-3, 0.5, 40}},
+	{"shifted lower", Transform{Lower: 2, Upper: math.Inf(1)}, []float64{2.01, 3, 10}},
+	{"upper", Transform{Lower: math.Inf(-1), Upper: 1}, []float64{-5, 0, 0.99}},
+	{"interval", Transform{Lower: -1, Upper: 3}, []float64{-0.999, 0, 2.5}},
+	{"unit", Transform{Lower: 0, Upper: 1}, []float64{1e-4, 0.5, 0.9999}},
+}
+
+func TestTransformRoundTrip(t *testing.T) {
+	for _, c := range testTransforms {
+		for _, x := range c.points {
+			y := c.t.Unconstrain(x)
+			if got := c.t.Constrain(y); !closeTo(got, x, 1e-9) {
+				t.Errorf("%s: Constrain(Unconstrain(%v)) = %v", c.name, x, got)
+			}
+		}
+		for _, y := range []float64{-30, -2, 0, 1.5, 30} {
+			x := c.t.Constrain(y)
+			if x < c.t.Lower || x > c.t.Upper {
+				t.Errorf("%s: Constrain(%v) = %v outside [%v, %v]", c.name, y, x, c.t.Lower, c.t.Upper)
+			}
+		}
+	}
+}
+
+func TestTransformJacobian(t *testing.T) {
+	for _, c := range testTransforms {
+		for _, x := range c.points {
+			y := c.t.Unconstrain(x)
+
+			dx := centralDifference(c.t.Constrain, y)
+			if got := c.t.DConstrain(y); !closeTo(got, dx, 1e-5) {
+				t.Errorf("%s: DConstrain(%v) = %v, finite differences give %v", c.name, y, got, dx)
+			}
+			if got, want := c.t.LogJacobian(y), math.Log(math.Abs(dx)); !closeTo(got, want, 1e-5) {
+				t.Errorf("%s: LogJacobian(%v) = %v, want log|dx/dy| = %v", c.name, y, got, want)
+			}
+			if got, want := c.t.DLogJacobian(y), centralDifference(c.t.LogJacobian, y); !closeTo(got, want, 1e-5) {
+				t.Errorf("%s: DLogJacobian(%v) = %v, finite differences give %v", c.name, y, got, want)
+			}
+		}
+	}
+}
+
+func TestTransformForPriors(t *testing.T) {
+	cases := []struct {
+		prior DistributionParams
+		want  Transform
+	}{
+		{DistributionParams{Dist: "Uniform", Params: map[string]float64{"Min": -2, "Max": 5}}, Transform{Lower: -2, Upper: 5}},
+		{DistributionParams{Dist: "Beta"}, Transform{Lower: 0, Upper: 1}},
+		{DistributionParams{Dist: "Exponential"}, Transform{Lower: 0, Upper: math.Inf(1)}},
+		{DistributionParams{Dist: "Pareto", Params: map[string]float64{"Xm": 1.5}}, Transform{Lower: 1.5, Upper: math.Inf(1)}},
+		{DistributionParams{Dist: "Normal"}, Transform{Lower: math.Inf(-1), Upper: math.Inf(1)}},
+	}
+	for _, c := range cases {
+		if got := TransformFor(c.prior); got != c.want {
+			t.Errorf("TransformFor(%s) = %+v, want %+v", c.prior.Dist, got, c.want)
+		}
+	}
+}